@@ -1,67 +1,343 @@
 package hw04lrucache
 
+import (
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrTooLarge = errors.New("item cost exceeds cache capacity")
+
 type Key string
 
-type Cache interface {
-	Set(key Key, value any) bool
-	Get(key Key) (any, bool)
+// TypedCache is a cache with keys of type K and values of type V.
+type TypedCache[K comparable, V any] interface {
+	Set(key K, value V) bool
+	SetWithTTL(key K, value V, ttl time.Duration) bool
+	TrySet(key K, value V) (bool, error)
+	Get(key K) (V, bool)
+	Peek(key K) (V, bool)
+	Delete(key K) bool
+	Keys() []K
+	Len() int
+	Resize(capacity int)
 	Clear()
+	Close()
+	OnEvict(fn func(key K, value V, reason EvictReason))
+	Stats() Stats
+	Save(w io.Writer) error
+	Load(r io.Reader) error
 }
 
-type lruCache struct {
+type Cache = TypedCache[Key, any]
+
+type lruCache[K comparable, V any] struct {
+	mu       sync.Mutex
 	capacity int
-	queue    *List
-	items    map[Key]*ListItem
+	maxCost  int64
+	cost     func(value V) int64
+	used     int64
+	policy   Policy
+	queue    evictionQueue[K, V]
+	items    map[K]*TypedListItem[cacheItem[K, V]]
+	ttl      time.Duration
+	clock    Clock
+	codec    Codec
+	sweeper  *sweeper
+	stats    Stats
+	onEvict  func(key K, value V, reason EvictReason)
+	evicted  []evictedItem[K, V]
+}
+
+type cacheItem[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+	cost      int64
+	freq      int   // Used by LFU.
+	segment   uint8 // Used by 2Q and ARC.
+}
+
+func NewCache(capacity int, opts ...Option) Cache {
+	return NewTypedCache[Key, any](capacity, opts...)
+}
+
+func NewTypedCache[K comparable, V any](capacity int, opts ...Option) TypedCache[K, V] {
+	cfg := newConfig(opts)
+	c := newLRUCache[K, V](capacity, int64(capacity), nil, cfg)
+	c.sweeper = startSweeper(cfg.sweepInterval, c.removeExpired)
+	return c
 }
 
-type cacheItem struct {
-	key   Key
-	value any
+// NewCostCache creates a cache bounded by the total cost of its values instead of their number.
+// Only LRU and LFU policies can be used, as 2Q and ARC size their queues and history in entries.
+// It panics if another policy is given.
+func NewCostCache(maxCost int64, cost func(value any) int64, opts ...Option) Cache {
+	return NewTypedCostCache[Key](maxCost, cost, opts...)
 }
 
-func NewCache(capacity int) Cache {
-	return &lruCache{
+func NewTypedCostCache[K comparable, V any](maxCost int64, cost func(value V) int64, opts ...Option) TypedCache[K, V] {
+	cfg := newConfig(opts)
+	if cfg.policy != LRU && cfg.policy != LFU {
+		panic("hw04lrucache: cost cache does not support " + cfg.policy.String() + " policy")
+	}
+	c := newLRUCache[K, V](0, maxCost, cost, cfg)
+	c.sweeper = startSweeper(cfg.sweepInterval, c.removeExpired)
+	return c
+}
+
+func newLRUCache[K comparable, V any](capacity int, maxCost int64, cost func(V) int64, cfg config) *lruCache[K, V] {
+	return &lruCache[K, V]{
 		capacity: capacity,
-		queue:    NewList(),
-		items:    make(map[Key]*ListItem, capacity),
+		maxCost:  maxCost,
+		cost:     cost,
+		policy:   cfg.policy,
+		queue:    newEvictionQueue[K, V](cfg.policy, capacity),
+		items:    make(map[K]*TypedListItem[cacheItem[K, V]], capacity),
+		ttl:      cfg.ttl,
+		clock:    cfg.clock,
+		codec:    cfg.codec,
+	}
+}
+
+func (c *lruCache[K, V]) Set(key K, value V) bool {
+	wasInCache, _ := c.set(key, value, expiresAt(c.clock, c.ttl))
+	return wasInCache
+}
+
+// SetWithTTL works like Set, but the entry expires after ttl instead of the default TTL.
+// Zero or negative ttl means the entry never expires.
+func (c *lruCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	wasInCache, _ := c.set(key, value, expiresAt(c.clock, ttl))
+	return wasInCache
+}
+
+// TrySet works like Set, but returns ErrTooLarge if the value alone exceeds the capacity.
+// Such a value is not stored, and a previous value of the key is removed.
+func (c *lruCache[K, V]) TrySet(key K, value V) (bool, error) {
+	return c.set(key, value, expiresAt(c.clock, c.ttl))
+}
+
+func (c *lruCache[K, V]) set(key K, value V, deadline time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	return c.put(key, value, deadline)
+}
+
+func (c *lruCache[K, V]) put(key K, value V, deadline time.Time) (bool, error) {
+	cost := c.costOf(value)
+	item, exists := c.items[key]
+	if cost > c.maxCost {
+		if exists {
+			c.remove(item, EvictRemoved)
+		}
+		return false, ErrTooLarge
+	}
+
+	if exists {
+		wasInCache := !expired(item.Value.expiresAt, c.clock.Now())
+		c.used += cost - item.Value.cost
+		item.Value.value = value
+		item.Value.expiresAt = deadline
+		item.Value.cost = cost
+		c.queue.touch(item)
+		c.evictUntilFits(key, 0)
+		return wasInCache, nil
+	}
+
+	c.evictUntilFits(key, cost)
+	c.items[key] = c.queue.push(cacheItem[K, V]{
+		key:       key,
+		value:     value,
+		expiresAt: deadline,
+		cost:      cost,
+	})
+	c.used += cost
+	return false, nil
+}
+
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if item, exists := c.items[key]; exists {
+		if !expired(item.Value.expiresAt, c.clock.Now()) {
+			c.stats.Hits++
+			c.queue.touch(item)
+			return item.Value.value, true
+		}
+		c.remove(item, EvictExpired)
 	}
+	c.stats.Misses++
+	var zero V
+	return zero, false
 }
 
-func (c *lruCache) Set(key Key, value any) bool {
+// Peek works like Get, but neither promotes the entry nor counts towards Stats.
+func (c *lruCache[K, V]) Peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
 	if item, exists := c.items[key]; exists {
-		item.Value.(*cacheItem).value = value
-		c.queue.MoveToFront(item)
-		return true
+		if !expired(item.Value.expiresAt, c.clock.Now()) {
+			return item.Value.value, true
+		}
+		c.remove(item, EvictExpired)
 	}
+	var zero V
+	return zero, false
+}
 
-	if c.capacity == 0 {
+// Delete removes the entry and reports whether it was in the cache.
+func (c *lruCache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	item, exists := c.items[key]
+	if !exists {
 		return false
 	}
+	wasInCache := !expired(item.Value.expiresAt, c.clock.Now())
+	c.remove(item, EvictRemoved)
+	return wasInCache
+}
+
+// Keys returns keys of entries that have not expired, from the most to the least valuable
+// according to the policy. For LRU it is the most recently used first.
+func (c *lruCache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	keys := make([]K, 0, c.queue.Len())
+	c.queue.walk(func(item *TypedListItem[cacheItem[K, V]]) {
+		if !expired(item.Value.expiresAt, now) {
+			keys = append(keys, item.Value.key)
+		}
+	})
+	slices.Reverse(keys)
+	return keys
+}
+
+// Len returns the number of entries, including expired ones that have not been removed yet.
+func (c *lruCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.queue.Len()
+}
+
+// Resize changes the capacity and evicts entries that no longer fit right away.
+// For caches made by NewCostCache the capacity is the total cost.
+func (c *lruCache[K, V]) Resize(capacity int) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	capacity = max(capacity, 0)
+	c.maxCost = int64(capacity)
+	if c.cost == nil {
+		c.capacity = capacity
+	}
 
-	if c.queue.Len() == c.capacity {
-		back := c.queue.Back()
-		c.queue.Remove(back)
-		delete(c.items, back.Value.(*cacheItem).key)
+	for c.used > c.maxCost && c.queue.Len() > 0 {
+		c.drop(c.queue.shrink(), EvictCapacity)
 	}
+	c.queue.resize(c.capacity)
+}
+
+func (c *lruCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.unlock()
 
-	newCacheItem := &cacheItem{
-		key:   key,
-		value: value,
+	if c.onEvict != nil {
+		c.queue.walk(func(item *TypedListItem[cacheItem[K, V]]) {
+			c.evicted = append(c.evicted, evictedItem[K, V]{item.Value.key, item.Value.value, EvictCleared})
+		})
 	}
-	newListItem := c.queue.PushFront(newCacheItem)
-	c.items[key] = newListItem
-	return false
+	c.queue = newEvictionQueue[K, V](c.policy, c.capacity)
+	c.items = make(map[K]*TypedListItem[cacheItem[K, V]], c.capacity)
+	c.used = 0
 }
 
-func (c *lruCache) Get(key Key) (any, bool) {
-	if item, exists := c.items[key]; exists {
-		c.queue.MoveToFront(item)
-		return item.Value.(*cacheItem).value, true
+// Close stops the background sweeper, if any. The cache stays usable afterwards.
+func (c *lruCache[K, V]) Close() {
+	if c.sweeper != nil {
+		c.sweeper.Close()
+	}
+}
+
+// OnEvict registers fn to be called for every entry leaving the cache.
+// fn is called without the cache lock held, so it may use the cache.
+func (c *lruCache[K, V]) OnEvict(fn func(key K, value V, reason EvictReason)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onEvict = fn
+}
+
+func (c *lruCache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.queue.Len()
+	return stats
+}
+
+func (c *lruCache[K, V]) removeExpired() {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	c.queue.walk(func(item *TypedListItem[cacheItem[K, V]]) {
+		if expired(item.Value.expiresAt, now) {
+			c.remove(item, EvictExpired)
+		}
+	})
+}
+
+// evictUntilFits makes room for an incoming item of the given cost.
+func (c *lruCache[K, V]) evictUntilFits(incoming K, cost int64) {
+	for c.used+cost > c.maxCost && c.queue.Len() > 0 {
+		c.drop(c.queue.evict(incoming), EvictCapacity)
+	}
+}
+
+func (c *lruCache[K, V]) costOf(value V) int64 {
+	if c.cost == nil {
+		return 1
 	}
-	return nil, false
+	return max(c.cost(value), 0)
 }
 
-func (c *lruCache) Clear() {
-	c.queue = NewList()
-	c.items = make(map[Key]*ListItem, c.capacity)
+func (c *lruCache[K, V]) remove(item *TypedListItem[cacheItem[K, V]], reason EvictReason) {
+	c.queue.remove(item)
+	c.drop(item, reason)
+}
+
+// drop forgets an item that is already unlinked from the queue.
+func (c *lruCache[K, V]) drop(item *TypedListItem[cacheItem[K, V]], reason EvictReason) {
+	delete(c.items, item.Value.key)
+	c.used -= item.Value.cost
+
+	if reason == EvictCapacity || reason == EvictExpired {
+		c.stats.Evictions++
+	}
+	if c.onEvict != nil {
+		c.evicted = append(c.evicted, evictedItem[K, V]{item.Value.key, item.Value.value, reason})
+	}
+}
+
+// unlock releases the lock and then reports entries evicted while it was held.
+func (c *lruCache[K, V]) unlock() {
+	evicted, onEvict := c.evicted, c.onEvict
+	c.evicted = nil
+	c.mu.Unlock()
+
+	for _, e := range evicted {
+		onEvict(e.key, e.value, e.reason)
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -60,8 +61,8 @@ func TestCache(t *testing.T) {
 
 		c.Clear()
 
-		require.Equal(t, 0, c.(*lruCache).queue.Len())
-		require.Equal(t, 0, len(c.(*lruCache).items))
+		require.Equal(t, 0, c.(*lruCache[Key, any]).queue.Len())
+		require.Equal(t, 0, len(c.(*lruCache[Key, any]).items))
 	})
 
 	t.Run("repeated add and remove", func(t *testing.T) {
@@ -106,8 +107,6 @@ func TestCache(t *testing.T) {
 }
 
 func TestCacheMultithreading(t *testing.T) {
-	t.Skip() // Remove me if task with asterisk completed.
-
 	c := NewCache(10)
 	wg := &sync.WaitGroup{}
 	wg.Add(2)
@@ -128,3 +127,185 @@ func TestCacheMultithreading(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestTypedCache(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		c := NewTypedCache[int, string](2)
+
+		wasInCache := c.Set(1, "one")
+		require.False(t, wasInCache)
+
+		wasInCache = c.Set(2, "two")
+		require.False(t, wasInCache)
+
+		val, ok := c.Get(1)
+		require.True(t, ok)
+		require.Equal(t, "one", val)
+
+		c.Set(3, "three") // 2 should be evicted
+
+		val, ok = c.Get(2)
+		require.False(t, ok)
+		require.Zero(t, val)
+
+		val, ok = c.Get(3)
+		require.True(t, ok)
+		require.Equal(t, "three", val)
+	})
+
+	t.Run("repeated access keeps queue consistent", func(t *testing.T) {
+		c := NewTypedCache[string, int](3)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Set("c", 3)
+		c.Get("a")
+		c.Get("b")
+		c.Get("a")
+		c.Set("c", 30)
+
+		queue := c.(*lruCache[string, int]).queue.(*lruQueue[string, int]).list
+		keys := make([]string, 0, queue.Len())
+		for i := queue.Front(); i != nil; i = i.Next {
+			keys = append(keys, i.Value.key)
+		}
+		require.Equal(t, []string{"c", "a", "b"}, keys)
+	})
+
+	t.Run("any-based cache is the typed one", func(t *testing.T) {
+		var c TypedCache[Key, any] = NewCache(1)
+
+		c.Set("a", []int{1})
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, []int{1}, val)
+	})
+}
+
+func TestCacheOperations(t *testing.T) {
+	t.Run("peek does not promote", func(t *testing.T) {
+		c := NewCache(2)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		val, ok := c.Peek("a")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+
+		c.Set("c", 3) // "a" should be evicted
+
+		_, ok = c.Peek("a")
+		require.False(t, ok)
+		require.Equal(t, Stats{Evictions: 1, Size: 2}, c.Stats())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c := NewCache(3)
+		records := recordEvictions(c)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		require.True(t, c.Delete("a"))
+		require.False(t, c.Delete("a"))
+		require.False(t, c.Delete("x"))
+
+		_, ok := c.Get("a")
+		require.False(t, ok)
+		require.Equal(t, 1, c.Len())
+		require.Equal(t, []evictRecord{{"a", 1, EvictRemoved}}, *records)
+	})
+
+	t.Run("keys in recency order", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, WithClock(clock))
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.SetWithTTL("c", 3, time.Second)
+		c.Set("d", 4)
+		c.Get("a")
+
+		require.Equal(t, []Key{"a", "d", "c", "b"}, c.Keys())
+		require.Equal(t, 4, c.Len())
+
+		clock.Advance(time.Second)
+		require.Equal(t, []Key{"a", "d", "b"}, c.Keys())
+	})
+
+	t.Run("resize", func(t *testing.T) {
+		c := NewCache(4)
+		records := recordEvictions(c)
+
+		for _, key := range []Key{"a", "b", "c", "d"} {
+			c.Set(key, key)
+		}
+		c.Get("a")
+
+		c.Resize(2)
+		require.Equal(t, []Key{"a", "d"}, c.Keys())
+		require.Equal(t, []evictRecord{{"b", Key("b"), EvictCapacity}, {"c", Key("c"), EvictCapacity}}, *records)
+
+		c.Resize(3)
+		c.Set("e", "e")
+		require.Equal(t, []Key{"e", "a", "d"}, c.Keys())
+
+		c.Resize(-1)
+		require.Equal(t, 0, c.Len())
+		require.False(t, c.Set("f", "f"))
+		require.Equal(t, 0, c.Len())
+	})
+
+	t.Run("resize cost cache", func(t *testing.T) {
+		c := NewCostCache(10, func(value any) int64 { return int64(value.(int)) })
+
+		c.Set("a", 4)
+		c.Set("b", 4)
+		c.Resize(5)
+
+		require.Equal(t, []Key{"b"}, c.Keys())
+	})
+
+	for _, policy := range policies {
+		t.Run("resize "+policy.String(), func(t *testing.T) {
+			c := NewCache(8, WithPolicy(policy))
+
+			for i := 0; i < 20; i++ {
+				c.Set(Key(strconv.Itoa(i)), i)
+				c.Get(Key(strconv.Itoa(i / 2)))
+			}
+
+			c.Resize(3)
+			require.Equal(t, 3, c.Len())
+
+			for i := 0; i < 20; i++ {
+				c.Set(Key(strconv.Itoa(i)), i)
+				require.LessOrEqual(t, c.Len(), 3)
+			}
+		})
+	}
+
+	t.Run("sharded", func(t *testing.T) {
+		c := NewShardedCache(8, 4)
+
+		for i := 0; i < 8; i++ {
+			c.Set(Key(strconv.Itoa(i)), i)
+		}
+		n := c.Len()
+		require.Len(t, c.Keys(), n)
+
+		val, ok := c.Peek(c.Keys()[0])
+		require.True(t, ok)
+		require.NotNil(t, val)
+
+		require.True(t, c.Delete(c.Keys()[0]))
+		require.Equal(t, n-1, c.Len())
+
+		c.Resize(4)
+		require.LessOrEqual(t, c.Len(), 4)
+		for _, shard := range c.(*shardedCache).shards {
+			require.Equal(t, 1, shard.capacity)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package hw04lrucache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostCache(t *testing.T) {
+	byteLen := func(value []byte) int64 { return int64(len(value)) }
+
+	t.Run("evicts until new item fits", func(t *testing.T) {
+		c := NewTypedCostCache[string](10, byteLen)
+
+		c.Set("a", make([]byte, 4))
+		c.Set("b", make([]byte, 4))
+		c.Set("c", make([]byte, 1))
+		c.Get("a")
+		c.Set("d", make([]byte, 6)) // "b" and "c" should be evicted
+
+		_, ok := c.Get("b")
+		require.False(t, ok)
+		_, ok = c.Get("c")
+		require.False(t, ok)
+
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Len(t, val, 4)
+
+		require.Equal(t, int64(10), c.(*lruCache[string, []byte]).used)
+	})
+
+	t.Run("growing value evicts others", func(t *testing.T) {
+		c := NewTypedCostCache[string](10, byteLen)
+
+		c.Set("a", make([]byte, 4))
+		c.Set("b", make([]byte, 4))
+		wasInCache := c.Set("b", make([]byte, 8)) // "a" should be evicted
+
+		require.True(t, wasInCache)
+		_, ok := c.Get("a")
+		require.False(t, ok)
+
+		val, ok := c.Get("b")
+		require.True(t, ok)
+		require.Len(t, val, 8)
+		require.Equal(t, int64(8), c.(*lruCache[string, []byte]).used)
+	})
+
+	t.Run("too large item is rejected", func(t *testing.T) {
+		c := NewTypedCostCache[string](10, byteLen)
+		records := make([]EvictReason, 0)
+		c.OnEvict(func(_ string, _ []byte, reason EvictReason) {
+			records = append(records, reason)
+		})
+
+		c.Set("a", make([]byte, 4))
+		c.Set("b", make([]byte, 4))
+
+		wasInCache, err := c.TrySet("c", make([]byte, 11))
+		require.ErrorIs(t, err, ErrTooLarge)
+		require.False(t, wasInCache)
+		require.Equal(t, 2, c.Stats().Size)
+		require.Empty(t, records)
+
+		wasInCache = c.Set("a", make([]byte, 11))
+		require.False(t, wasInCache)
+		_, ok := c.Get("a")
+		require.False(t, ok)
+		require.Equal(t, []EvictReason{EvictRemoved}, records)
+
+		val, ok := c.Get("b")
+		require.True(t, ok)
+		require.Len(t, val, 4)
+		require.Equal(t, int64(4), c.(*lruCache[string, []byte]).used)
+	})
+
+	t.Run("clear resets used cost", func(t *testing.T) {
+		c := NewCostCache(10, func(value any) int64 { return int64(value.(int)) })
+
+		c.Set("a", 6)
+		c.Clear()
+		c.Set("b", 10)
+
+		val, ok := c.Get("b")
+		require.True(t, ok)
+		require.Equal(t, 10, val)
+	})
+
+	t.Run("policies sized in entries are rejected", func(t *testing.T) {
+		byCount := func(any) int64 { return 1 }
+		require.Panics(t, func() { NewCostCache(10, byCount, WithPolicy(TwoQueue)) })
+		require.Panics(t, func() { NewCostCache(10, byCount, WithPolicy(ARC)) })
+
+		c := NewCostCache(10, byCount, WithPolicy(LFU))
+		defer c.Close()
+		c.Set("a", 1)
+		_, ok := c.Get("a")
+		require.True(t, ok)
+	})
+
+	t.Run("count capacity rejects everything when zero", func(t *testing.T) {
+		c := NewCache(0)
+
+		_, err := c.TrySet("a", 1)
+		require.ErrorIs(t, err, ErrTooLarge)
+	})
+}
@@ -0,0 +1,61 @@
+package hw04lrucache
+
+type EvictReason int
+
+const (
+	// EvictCapacity means the entry was pushed out to make room for a new one.
+	EvictCapacity EvictReason = iota
+	// EvictRemoved means the entry was removed explicitly.
+	EvictRemoved
+	// EvictCleared means the entry was dropped by Clear.
+	EvictCleared
+	// EvictExpired means the entry outlived its TTL.
+	EvictExpired
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictRemoved:
+		return "removed"
+	case EvictCleared:
+		return "cleared"
+	case EvictExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// Stats is a snapshot of cache counters.
+// Evictions counts entries dropped by the cache itself, i.e. because of capacity or expiry.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+func (s Stats) add(other Stats) Stats {
+	return Stats{
+		Hits:      s.Hits + other.Hits,
+		Misses:    s.Misses + other.Misses,
+		Evictions: s.Evictions + other.Evictions,
+		Size:      s.Size + other.Size,
+	}
+}
+
+type evictedItem[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
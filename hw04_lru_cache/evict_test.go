@@ -0,0 +1,147 @@
+package hw04lrucache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type evictRecord struct {
+	key    Key
+	value  any
+	reason EvictReason
+}
+
+func recordEvictions(c Cache) *[]evictRecord {
+	records := &[]evictRecord{}
+	c.OnEvict(func(key Key, value any, reason EvictReason) {
+		*records = append(*records, evictRecord{key, value, reason})
+	})
+	return records
+}
+
+func TestCacheOnEvict(t *testing.T) {
+	t.Run("capacity", func(t *testing.T) {
+		c := NewCache(2)
+		records := recordEvictions(c)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3) // "b" should be evicted
+
+		require.Equal(t, []evictRecord{{"b", 2, EvictCapacity}}, *records)
+	})
+
+	t.Run("update does not evict", func(t *testing.T) {
+		c := NewCache(2)
+		records := recordEvictions(c)
+
+		c.Set("a", 1)
+		c.Set("a", 2)
+
+		require.Empty(t, *records)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		c := NewCache(3)
+		records := recordEvictions(c)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Clear()
+
+		require.Equal(t, []evictRecord{{"a", 1, EvictCleared}, {"b", 2, EvictCleared}}, *records)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(3, WithClock(clock))
+		records := recordEvictions(c)
+
+		c.SetWithTTL("a", 1, time.Second)
+		c.SetWithTTL("b", 2, time.Second)
+		clock.Advance(time.Second)
+
+		c.Get("a")
+		c.(*lruCache[Key, any]).removeExpired()
+
+		require.Equal(t, []evictRecord{{"a", 1, EvictExpired}, {"b", 2, EvictExpired}}, *records)
+	})
+
+	t.Run("callback may use the cache", func(t *testing.T) {
+		c := NewCache(1)
+		evicted := NewCache(10)
+		c.OnEvict(func(key Key, value any, _ EvictReason) {
+			evicted.Set(key, value)
+			c.Get(key)
+		})
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		val, ok := evicted.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+	})
+
+	t.Run("sharded", func(t *testing.T) {
+		c := NewShardedCache(1, 1)
+		records := recordEvictions(c)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		require.Equal(t, []evictRecord{{"a", 1, EvictCapacity}}, *records)
+	})
+}
+
+func TestCacheStats(t *testing.T) {
+	t.Run("hits, misses and evictions", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(2, WithClock(clock))
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Get("a")
+		c.Get("x")
+		c.Set("c", 3)                     // "b" is evicted
+		c.SetWithTTL("d", 4, time.Second) // "a" is evicted
+		clock.Advance(time.Second)
+		c.Get("d") // expired
+
+		require.Equal(t, Stats{Hits: 2, Misses: 2, Evictions: 3, Size: 1}, c.Stats())
+		require.InDelta(t, 0.5, c.Stats().HitRatio(), 1e-9)
+	})
+
+	t.Run("clear keeps counters", func(t *testing.T) {
+		c := NewCache(2)
+
+		c.Set("a", 1)
+		c.Get("a")
+		c.Clear()
+
+		require.Equal(t, Stats{Hits: 1}, c.Stats())
+	})
+
+	t.Run("empty hit ratio", func(t *testing.T) {
+		require.Zero(t, NewCache(1).Stats().HitRatio())
+	})
+
+	t.Run("sharded sums shards", func(t *testing.T) {
+		c := NewShardedCache(4, 4)
+
+		for _, key := range []Key{"a", "b", "c", "d"} {
+			c.Set(key, key)
+			c.Get(key)
+		}
+		c.Get("x")
+
+		stats := c.Stats()
+		require.Equal(t, uint64(4), stats.Hits)
+		require.Equal(t, uint64(1), stats.Misses)
+		require.Equal(t, 4, stats.Size+int(stats.Evictions))
+	})
+}
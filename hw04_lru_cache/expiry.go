@@ -0,0 +1,65 @@
+package hw04lrucache
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func expiresAt(clock Clock, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return clock.Now().Add(ttl)
+}
+
+func expired(deadline, now time.Time) bool {
+	return !deadline.IsZero() && !now.Before(deadline)
+}
+
+type sweeper struct {
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func startSweeper(interval time.Duration, sweep func()) *sweeper {
+	s := &sweeper{stop: make(chan struct{})}
+	if interval <= 0 {
+		return s
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				sweep()
+			}
+		}
+	}()
+	return s
+}
+
+func (s *sweeper) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	s.wg.Wait()
+}
@@ -0,0 +1,187 @@
+package hw04lrucache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCacheTTL(t *testing.T) {
+	t.Run("default ttl", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, WithTTL(time.Minute), WithClock(clock))
+		defer c.Close()
+
+		c.Set("aaa", 100)
+
+		clock.Advance(time.Minute - time.Second)
+		val, ok := c.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 100, val)
+
+		clock.Advance(time.Second)
+		val, ok = c.Get("aaa")
+		require.False(t, ok)
+		require.Nil(t, val)
+		require.Equal(t, 0, c.(*lruCache[Key, any]).queue.Len())
+	})
+
+	t.Run("per-entry ttl overrides default", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, WithTTL(time.Minute), WithClock(clock))
+		defer c.Close()
+
+		c.SetWithTTL("short", 1, time.Second)
+		c.SetWithTTL("forever", 2, 0)
+		c.Set("default", 3)
+
+		clock.Advance(time.Second)
+		_, ok := c.Get("short")
+		require.False(t, ok)
+
+		clock.Advance(time.Hour)
+		_, ok = c.Get("default")
+		require.False(t, ok)
+
+		val, ok := c.Get("forever")
+		require.True(t, ok)
+		require.Equal(t, 2, val)
+	})
+
+	t.Run("no ttl by default", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, WithClock(clock))
+		defer c.Close()
+
+		c.Set("aaa", 100)
+		clock.Advance(24 * time.Hour)
+
+		_, ok := c.Get("aaa")
+		require.True(t, ok)
+	})
+
+	t.Run("set over expired entry", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, WithTTL(time.Minute), WithClock(clock))
+		defer c.Close()
+
+		c.Set("aaa", 100)
+		clock.Advance(time.Minute)
+
+		wasInCache := c.Set("aaa", 200)
+		require.False(t, wasInCache)
+
+		clock.Advance(time.Minute - time.Second)
+		val, ok := c.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 200, val)
+	})
+
+	t.Run("sweep removes only expired entries", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, WithClock(clock))
+		defer c.Close()
+
+		c.SetWithTTL("a", 1, time.Second)
+		c.SetWithTTL("b", 2, time.Hour)
+		c.SetWithTTL("c", 3, time.Second)
+		c.Set("d", 4)
+
+		clock.Advance(time.Second)
+		c.(*lruCache[Key, any]).removeExpired()
+
+		lru := c.(*lruCache[Key, any])
+		require.Equal(t, 2, lru.queue.Len())
+		require.Len(t, lru.items, 2)
+		require.Contains(t, lru.items, Key("b"))
+		require.Contains(t, lru.items, Key("d"))
+	})
+
+	t.Run("background sweeper", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, WithTTL(time.Second), WithClock(clock), WithSweepInterval(time.Millisecond))
+		defer c.Close()
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		clock.Advance(time.Second)
+
+		lru := c.(*lruCache[Key, any])
+		require.Eventually(t, func() bool {
+			lru.mu.Lock()
+			defer lru.mu.Unlock()
+			return lru.queue.Len() == 0
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("close is idempotent", func(t *testing.T) {
+		c := NewCache(5, WithSweepInterval(time.Millisecond))
+		c.Close()
+		c.Close()
+	})
+}
+
+func TestShardedCacheTTL(t *testing.T) {
+	t.Run("sharded cache", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewShardedCache(8, 4, WithTTL(time.Minute), WithClock(clock))
+		defer c.Close()
+
+		c.Set("a", 1)
+		c.SetWithTTL("b", 2, time.Hour)
+		clock.Advance(time.Minute)
+
+		_, ok := c.Get("a")
+		require.False(t, ok)
+
+		_, ok = c.Get("b")
+		require.True(t, ok)
+	})
+
+	t.Run("sharded background sweeper", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewShardedCache(8, 4, WithTTL(time.Second), WithClock(clock), WithSweepInterval(time.Millisecond))
+		defer c.Close()
+
+		for _, key := range []Key{"a", "b", "c", "d"} {
+			c.Set(key, key)
+		}
+		clock.Advance(time.Second)
+
+		sharded := c.(*shardedCache)
+		require.Eventually(t, func() bool {
+			for _, shard := range sharded.shards {
+				shard.mu.Lock()
+				n := shard.queue.Len()
+				shard.mu.Unlock()
+				if n != 0 {
+					return false
+				}
+			}
+			return true
+		}, time.Second, time.Millisecond)
+	})
+}
@@ -1,66 +1,77 @@
 package hw04lrucache
 
-type ListItem struct {
-	Value any
-	Next  *ListItem
-	Prev  *ListItem
+import "iter"
+
+type TypedListItem[T any] struct {
+	Value T
+	Next  *TypedListItem[T]
+	Prev  *TypedListItem[T]
 }
 
-type List struct {
-	front *ListItem
-	back  *ListItem
+// TypedList is a doubly linked list of values of type T.
+type TypedList[T any] struct {
+	front *TypedListItem[T]
+	back  *TypedListItem[T]
 	len   int
 }
 
+type (
+	ListItem = TypedListItem[any]
+	List     = TypedList[any]
+)
+
 func NewList() *List {
-	return &List{}
+	return NewTypedList[any]()
+}
+
+func NewTypedList[T any]() *TypedList[T] {
+	return &TypedList[T]{}
 }
 
-func (l *List) Len() int {
+func (l *TypedList[T]) Len() int {
 	return l.len
 }
 
-func (l *List) Front() *ListItem {
+func (l *TypedList[T]) Front() *TypedListItem[T] {
 	return l.front
 }
 
-func (l *List) Back() *ListItem {
+func (l *TypedList[T]) Back() *TypedListItem[T] {
 	return l.back
 }
 
-func (l *List) PushFront(v any) *ListItem {
-	newItem := &ListItem{
-		Value: v,
-		Next:  l.front,
-		Prev:  nil,
-	}
-	if l.front != nil {
-		l.front.Prev = newItem
-	} else {
-		l.back = newItem
+func (l *TypedList[T]) PushFront(v T) *TypedListItem[T] {
+	return l.linkFront(&TypedListItem[T]{Value: v})
+}
+
+func (l *TypedList[T]) PushBack(v T) *TypedListItem[T] {
+	return l.linkBack(&TypedListItem[T]{Value: v})
+}
+
+// InsertBefore inserts v right before mark, which must be an item of l.
+func (l *TypedList[T]) InsertBefore(v T, mark *TypedListItem[T]) *TypedListItem[T] {
+	if mark == l.front {
+		return l.PushFront(v)
 	}
-	l.front = newItem
-	l.len++
-	return newItem
+	return l.linkAfter(&TypedListItem[T]{Value: v}, mark.Prev)
 }
 
-func (l *List) PushBack(v any) *ListItem {
-	newItem := &ListItem{
-		Value: v,
-		Next:  nil,
-		Prev:  l.back,
+// InsertAfter inserts v right after mark, which must be an item of l.
+func (l *TypedList[T]) InsertAfter(v T, mark *TypedListItem[T]) *TypedListItem[T] {
+	if mark == l.back {
+		return l.PushBack(v)
 	}
-	if l.back != nil {
-		l.back.Next = newItem
-	} else {
-		l.front = newItem
+	return l.linkAfter(&TypedListItem[T]{Value: v}, mark)
+}
+
+// PushBackList appends copies of the values of other. other may be l itself.
+func (l *TypedList[T]) PushBackList(other *TypedList[T]) {
+	for i, n := other.Front(), other.Len(); n > 0; i, n = i.Next, n-1 {
+		l.PushBack(i.Value)
 	}
-	l.back = newItem
-	l.len++
-	return newItem
 }
 
-func (l *List) Remove(i *ListItem) {
+func (l *TypedList[T]) Remove(i *TypedListItem[T]) {
 	if i.Prev != nil {
 		i.Prev.Next = i.Next
 	} else {
@@ -71,13 +82,89 @@ func (l *List) Remove(i *ListItem) {
 	} else {
 		l.back = i.Prev
 	}
+	i.Next = nil
+	i.Prev = nil
 	l.len--
 }
 
-func (l *List) MoveToFront(i *ListItem) {
-	if i == l.front {
+// MoveToFront relinks i in place, so pointers to it stay valid.
+func (l *TypedList[T]) MoveToFront(i *TypedListItem[T]) {
+	if i == nil || i == l.front {
+		return
+	}
+	l.Remove(i)
+	l.linkFront(i)
+}
+
+// MoveToBack relinks i in place, so pointers to it stay valid.
+func (l *TypedList[T]) MoveToBack(i *TypedListItem[T]) {
+	if i == nil || i == l.back {
 		return
 	}
 	l.Remove(i)
-	l.PushFront(i.Value)
+	l.linkBack(i)
+}
+
+// All yields values from front to back. The current item may be removed during iteration.
+func (l *TypedList[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := l.front; i != nil; {
+			next := i.Next
+			if !yield(i.Value) {
+				return
+			}
+			i = next
+		}
+	}
+}
+
+// Backward yields values from back to front. The current item may be removed during iteration.
+func (l *TypedList[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := l.back; i != nil; {
+			prev := i.Prev
+			if !yield(i.Value) {
+				return
+			}
+			i = prev
+		}
+	}
+}
+
+// linkFront inserts an item that does not belong to any list at the front of l.
+func (l *TypedList[T]) linkFront(i *TypedListItem[T]) *TypedListItem[T] {
+	i.Prev = nil
+	i.Next = l.front
+	if l.front != nil {
+		l.front.Prev = i
+	} else {
+		l.back = i
+	}
+	l.front = i
+	l.len++
+	return i
+}
+
+// linkBack inserts an item that does not belong to any list at the back of l.
+func (l *TypedList[T]) linkBack(i *TypedListItem[T]) *TypedListItem[T] {
+	i.Next = nil
+	i.Prev = l.back
+	if l.back != nil {
+		l.back.Next = i
+	} else {
+		l.front = i
+	}
+	l.back = i
+	l.len++
+	return i
+}
+
+// linkAfter inserts an item that does not belong to any list after mark, which is not the back of l.
+func (l *TypedList[T]) linkAfter(i, mark *TypedListItem[T]) *TypedListItem[T] {
+	i.Prev = mark
+	i.Next = mark.Next
+	mark.Next.Prev = i
+	mark.Next = i
+	l.len++
+	return i
 }
@@ -1,6 +1,7 @@
 package hw04lrucache
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -72,3 +73,203 @@ func TestList(t *testing.T) {
 		require.Nil(t, l.Back())
 	})
 }
+
+func TestListExtended(t *testing.T) {
+	t.Run("move keeps items", func(t *testing.T) {
+		l := NewList()
+		first := l.PushBack(10)
+		l.PushBack(20)
+		last := l.PushBack(30) // [10, 20, 30]
+
+		l.MoveToFront(last) // [30, 10, 20]
+		require.Same(t, last, l.Front())
+		require.Nil(t, last.Prev)
+		require.Same(t, first, last.Next)
+
+		l.MoveToBack(last) // [10, 20, 30]
+		require.Same(t, last, l.Back())
+		require.Nil(t, last.Next)
+
+		l.MoveToBack(first) // [20, 30, 10]
+		l.MoveToBack(first) // [20, 30, 10]
+		l.MoveToBack(nil)
+		require.Equal(t, []any{20, 30, 10}, slices.Collect(l.All()))
+		require.Equal(t, 3, l.Len())
+	})
+
+	t.Run("insert before and after", func(t *testing.T) {
+		l := NewList()
+		middle := l.PushBack(20) // [20]
+
+		l.InsertBefore(10, middle)   // [10, 20]
+		l.InsertAfter(40, middle)    // [10, 20, 40]
+		l.InsertAfter(30, middle)    // [10, 20, 30, 40]
+		l.InsertBefore(0, l.Front()) // [0, 10, 20, 30, 40]
+		l.InsertAfter(50, l.Back())  // [0, 10, 20, 30, 40, 50]
+		l.InsertBefore(15, middle)   // [0, 10, 15, 20, 30, 40, 50]
+
+		require.Equal(t, 7, l.Len())
+		require.Equal(t, []any{0, 10, 15, 20, 30, 40, 50}, slices.Collect(l.All()))
+		require.Equal(t, []any{50, 40, 30, 20, 15, 10, 0}, slices.Collect(l.Backward()))
+	})
+
+	t.Run("push back list", func(t *testing.T) {
+		l := NewList()
+		l.PushBack(1)
+		l.PushBack(2)
+
+		other := NewList()
+		other.PushBack(3)
+
+		l.PushBackList(other)
+		l.PushBackList(l)
+		l.PushBackList(NewList())
+
+		require.Equal(t, []any{1, 2, 3, 1, 2, 3}, slices.Collect(l.All()))
+		require.Equal(t, []any{3}, slices.Collect(other.All()))
+	})
+
+	t.Run("iteration stops and allows removal", func(t *testing.T) {
+		l := NewTypedList[int]()
+		items := make(map[int]*TypedListItem[int])
+		for i := 0; i < 5; i++ {
+			items[i] = l.PushBack(i)
+		}
+
+		for v := range l.All() {
+			if v%2 == 0 {
+				l.Remove(items[v])
+			}
+		}
+		require.Equal(t, []int{1, 3}, slices.Collect(l.All()))
+
+		var seen []int
+		for v := range l.Backward() {
+			seen = append(seen, v)
+			break
+		}
+		require.Equal(t, []int{3}, seen)
+	})
+
+	t.Run("removed item is detached", func(t *testing.T) {
+		l := NewList()
+		l.PushBack(1)
+		middle := l.PushBack(2)
+		l.PushBack(3)
+
+		l.Remove(middle)
+		require.Nil(t, middle.Next)
+		require.Nil(t, middle.Prev)
+	})
+}
+
+func FuzzList(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6})
+	f.Add([]byte{1, 1, 1, 3, 9, 4, 14, 5, 2, 2, 2, 6, 7})
+	f.Add([]byte{0, 0, 0, 0, 24, 19, 13, 3, 7, 255})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		l := NewTypedList[int]()
+		var model []*TypedListItem[int]
+
+		for n, op := range ops {
+			pos := 0
+			if len(model) > 0 {
+				pos = int(op>>3) % len(model)
+			}
+
+			switch op % 8 {
+			case 0:
+				model = slices.Insert(model, 0, l.PushFront(n))
+			case 1:
+				model = append(model, l.PushBack(n))
+			case 2:
+				if len(model) > 0 {
+					l.Remove(model[pos])
+					model = slices.Delete(model, pos, pos+1)
+				}
+			case 3:
+				if len(model) > 0 {
+					item := model[pos]
+					l.MoveToFront(item)
+					model = slices.Insert(slices.Delete(model, pos, pos+1), 0, item)
+				}
+			case 4:
+				if len(model) > 0 {
+					item := model[pos]
+					l.MoveToBack(item)
+					model = append(slices.Delete(model, pos, pos+1), item)
+				}
+			case 5:
+				if len(model) > 0 {
+					model = slices.Insert(model, pos, l.InsertBefore(n, model[pos]))
+				}
+			case 6:
+				if len(model) > 0 {
+					model = slices.Insert(model, pos+1, l.InsertAfter(n, model[pos]))
+				}
+			case 7:
+				if len(model) < 64 {
+					l.PushBackList(l)
+					added := make([]*TypedListItem[int], 0, len(model))
+					for item := l.Back(); len(added) < len(model); item = item.Prev {
+						added = append(added, item)
+					}
+					slices.Reverse(added)
+					model = append(model, added...)
+				}
+			}
+
+			checkList(t, l, model)
+		}
+	})
+}
+
+func checkList(t *testing.T, l *TypedList[int], model []*TypedListItem[int]) {
+	t.Helper()
+
+	require.Equal(t, len(model), l.Len())
+	if len(model) == 0 {
+		require.Nil(t, l.Front())
+		require.Nil(t, l.Back())
+		return
+	}
+
+	require.Same(t, model[0], l.Front())
+	require.Same(t, model[len(model)-1], l.Back())
+	require.Nil(t, l.Front().Prev)
+	require.Nil(t, l.Back().Next)
+
+	i := l.Front()
+	for _, want := range model {
+		require.Same(t, want, i)
+		if i.Next != nil {
+			require.Same(t, i, i.Next.Prev)
+		}
+		i = i.Next
+	}
+	require.Nil(t, i)
+
+	values := make([]int, 0, len(model))
+	for _, item := range model {
+		values = append(values, item.Value)
+	}
+	require.Equal(t, values, slices.Collect(l.All()))
+	slices.Reverse(values)
+	require.Equal(t, values, slices.Collect(l.Backward()))
+}
+
+func TestTypedList(t *testing.T) {
+	l := NewTypedList[string]()
+
+	l.PushBack("b")  // [b]
+	l.PushFront("a") // [a, b]
+	l.PushBack("c")  // [a, b, c]
+	l.MoveToFront(l.Back())
+
+	elems := make([]string, 0, l.Len())
+	for i := l.Front(); i != nil; i = i.Next {
+		elems = append(elems, i.Value)
+	}
+	require.Equal(t, []string{"c", "a", "b"}, elems)
+}
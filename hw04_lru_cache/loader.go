@@ -0,0 +1,146 @@
+package hw04lrucache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	defaultErrorTTL     = time.Second
+	errorsCacheCapacity = 1024
+)
+
+type LoadFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// PanicError is returned to the callers waiting for a load that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("load panicked: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// TypedLoader makes a cache read-through: missing values are loaded on demand,
+// and concurrent loads of the same key are merged into one.
+type TypedLoader[K comparable, V any] struct {
+	cache  TypedCache[K, V]
+	errors TypedCache[K, error]
+
+	mu    sync.Mutex
+	calls map[K]*loadCall[V]
+}
+
+type Loader = TypedLoader[Key, any]
+
+type loadCall[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func NewLoader(cache Cache, opts ...Option) *Loader {
+	return NewTypedLoader(cache, opts...)
+}
+
+// NewTypedLoader wraps a goroutine-safe cache. Only WithErrorTTL and WithClock options are used.
+func NewTypedLoader[K comparable, V any](cache TypedCache[K, V], opts ...Option) *TypedLoader[K, V] {
+	cfg := newConfig(opts)
+	errorsCapacity := errorsCacheCapacity
+	if cfg.errorTTL <= 0 {
+		errorsCapacity = 0
+	}
+	return &TypedLoader[K, V]{
+		cache:  cache,
+		errors: NewTypedCache[K, error](errorsCapacity, WithTTL(cfg.errorTTL), WithClock(cfg.clock)),
+		calls:  make(map[K]*loadCall[V]),
+	}
+}
+
+// GetOrLoad returns the cached value of key or loads it with load and caches the result.
+// A load error is returned to all callers waiting for it and, unless caused by cancellation,
+// to those asking for the same key during the error TTL.
+// A caller whose ctx is done stops waiting; the load itself is cancelled once nobody waits for it.
+// A panic of load is returned to the waiting callers as *PanicError.
+func (l *TypedLoader[K, V]) GetOrLoad(ctx context.Context, key K, load LoadFunc[K, V]) (V, error) {
+	if value, ok := l.cache.Get(key); ok {
+		return value, nil
+	}
+	if err, ok := l.errors.Get(key); ok {
+		var zero V
+		return zero, err
+	}
+
+	l.mu.Lock()
+	call, ok := l.calls[key]
+	if !ok {
+		loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &loadCall[V]{done: make(chan struct{}), cancel: cancel}
+		l.calls[key] = call
+		go l.load(loadCtx, key, load, call)
+	}
+	call.waiters++
+	l.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		l.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			if l.calls[key] == call {
+				delete(l.calls, key)
+			}
+		}
+		l.mu.Unlock()
+
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (l *TypedLoader[K, V]) load(ctx context.Context, key K, load LoadFunc[K, V], call *loadCall[V]) {
+	defer call.cancel()
+
+	value, err := safeLoad(ctx, key, load)
+	switch {
+	case err == nil:
+		l.cache.Set(key, value)
+	case !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded):
+		l.errors.Set(key, err)
+	}
+
+	l.mu.Lock()
+	if l.calls[key] == call {
+		delete(l.calls, key)
+	}
+	l.mu.Unlock()
+
+	call.value, call.err = value, err
+	close(call.done)
+}
+
+// safeLoad calls load, turning its panic into *PanicError, as the load runs in its own goroutine.
+func safeLoad[K comparable, V any](ctx context.Context, key K, load LoadFunc[K, V]) (value V, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return load(ctx, key)
+}
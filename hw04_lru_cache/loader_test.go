@@ -0,0 +1,242 @@
+package hw04lrucache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader(t *testing.T) {
+	t.Run("loads missing value once", func(t *testing.T) {
+		c := NewCache(10)
+		l := NewLoader(c)
+
+		var calls int32
+		load := func(_ context.Context, key Key) (any, error) {
+			atomic.AddInt32(&calls, 1)
+			return string(key) + "!", nil
+		}
+
+		for i := 0; i < 3; i++ {
+			val, err := l.GetOrLoad(context.Background(), "a", load)
+			require.NoError(t, err)
+			require.Equal(t, "a!", val)
+		}
+		require.Equal(t, int32(1), calls)
+
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, "a!", val)
+	})
+
+	t.Run("concurrent loads are merged", func(t *testing.T) {
+		l := NewLoader(NewCache(10))
+
+		var calls int32
+		release := make(chan struct{})
+		load := func(_ context.Context, _ Key) (any, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return 42, nil
+		}
+
+		const callers = 50
+		wg := sync.WaitGroup{}
+		wg.Add(callers)
+		for i := 0; i < callers; i++ {
+			go func() {
+				defer wg.Done()
+				val, err := l.GetOrLoad(context.Background(), "a", load)
+				if err != nil || val != 42 {
+					t.Errorf("got %v, %v", val, err)
+				}
+			}()
+		}
+
+		require.Eventually(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.calls["a"] != nil && l.calls["a"].waiters == callers
+		}, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Equal(t, int32(1), calls)
+	})
+
+	t.Run("errors are cached for a short time", func(t *testing.T) {
+		clock := newFakeClock()
+		l := NewLoader(NewCache(10), WithClock(clock), WithErrorTTL(time.Second))
+
+		errBackend := errors.New("backend is down")
+		var calls int32
+		load := func(_ context.Context, _ Key) (any, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return nil, errBackend
+			}
+			return 1, nil
+		}
+
+		_, err := l.GetOrLoad(context.Background(), "a", load)
+		require.ErrorIs(t, err, errBackend)
+
+		_, err = l.GetOrLoad(context.Background(), "a", load)
+		require.ErrorIs(t, err, errBackend)
+		require.Equal(t, int32(1), calls)
+
+		clock.Advance(time.Second)
+		val, err := l.GetOrLoad(context.Background(), "a", load)
+		require.NoError(t, err)
+		require.Equal(t, 1, val)
+		require.Equal(t, int32(2), calls)
+	})
+
+	t.Run("negative caching can be disabled", func(t *testing.T) {
+		l := NewLoader(NewCache(10), WithErrorTTL(0))
+
+		var calls int32
+		load := func(_ context.Context, _ Key) (any, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, errors.New("fail")
+		}
+
+		l.GetOrLoad(context.Background(), "a", load)
+		l.GetOrLoad(context.Background(), "a", load)
+		require.Equal(t, int32(2), calls)
+	})
+}
+
+func TestLoaderPanic(t *testing.T) {
+	l := NewLoader(NewCache(10))
+
+	release := make(chan struct{})
+	load := func(_ context.Context, _ Key) (any, error) {
+		<-release
+		panic("boom")
+	}
+
+	const callers = 2
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, err := l.GetOrLoad(context.Background(), "a", load)
+			errs <- err
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.calls["a"] != nil && l.calls["a"].waiters == callers
+	}, time.Second, time.Millisecond)
+	close(release)
+
+	for i := 0; i < callers; i++ {
+		var panicErr *PanicError
+		require.ErrorAs(t, <-errs, &panicErr)
+		require.Equal(t, "boom", panicErr.Value)
+		require.Contains(t, string(panicErr.Stack), "loader_test.go")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	require.Empty(t, l.calls)
+}
+
+func TestLoaderCancellation(t *testing.T) {
+	t.Run("cancelled caller stops waiting", func(t *testing.T) {
+		l := NewLoader(NewCache(10))
+
+		release := make(chan struct{})
+		defer close(release)
+		load := func(_ context.Context, _ Key) (any, error) {
+			<-release
+			return 1, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := l.GetOrLoad(ctx, "a", load)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("load is cancelled when nobody waits", func(t *testing.T) {
+		l := NewLoader(NewCache(10))
+
+		cancelled := make(chan struct{})
+		load := func(ctx context.Context, _ Key) (any, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := l.GetOrLoad(ctx, "a", load)
+			errCh <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.calls["a"] != nil
+		}, time.Second, time.Millisecond)
+		cancel()
+
+		require.ErrorIs(t, <-errCh, context.Canceled)
+		<-cancelled
+
+		// Cancellation is not cached as a failure.
+		val, err := l.GetOrLoad(context.Background(), "a", func(context.Context, Key) (any, error) {
+			return 2, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, val)
+	})
+
+	t.Run("load continues for remaining waiters", func(t *testing.T) {
+		l := NewLoader(NewCache(10))
+
+		release := make(chan struct{})
+		load := func(ctx context.Context, _ Key) (any, error) {
+			select {
+			case <-release:
+				return 1, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		firstErr := make(chan error, 1)
+		go func() {
+			_, err := l.GetOrLoad(ctx, "a", load)
+			firstErr <- err
+		}()
+
+		secondVal := make(chan any, 1)
+		go func() {
+			val, _ := l.GetOrLoad(context.Background(), "a", load)
+			secondVal <- val
+		}()
+
+		require.Eventually(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.calls["a"] != nil && l.calls["a"].waiters == 2
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		require.ErrorIs(t, <-firstErr, context.Canceled)
+
+		close(release)
+		require.Equal(t, 1, <-secondVal)
+	})
+}
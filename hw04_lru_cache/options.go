@@ -0,0 +1,71 @@
+package hw04lrucache
+
+import "time"
+
+type Option func(*config)
+
+type config struct {
+	ttl           time.Duration
+	clock         Clock
+	sweepInterval time.Duration
+	policy        Policy
+	errorTTL      time.Duration
+	codec         Codec
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		clock:    systemClock{},
+		errorTTL: defaultErrorTTL,
+		codec:    GobCodec{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithTTL sets the time to live for entries added by Set.
+// Zero or negative duration means entries never expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// WithClock replaces the wall clock used to expire entries.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithSweepInterval starts a background goroutine that removes expired entries
+// every interval. The goroutine is stopped by Cache.Close.
+func WithSweepInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.sweepInterval = interval
+	}
+}
+
+// WithPolicy sets the eviction policy, LRU by default.
+func WithPolicy(policy Policy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// WithErrorTTL sets how long a Loader remembers a failed load, one second by default.
+// Zero or negative duration disables negative caching.
+func WithErrorTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.errorTTL = ttl
+	}
+}
+
+// WithCodec sets the codec used by Save and Load, gob by default.
+func WithCodec(codec Codec) Option {
+	return func(c *config) {
+		c.codec = codec
+	}
+}
@@ -0,0 +1,160 @@
+package hw04lrucache
+
+import "fmt"
+
+// Policy selects which entry the cache evicts when it is full.
+type Policy int
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU Policy = iota
+	// LFU evicts the least frequently used entry, the least recently used one among equals.
+	LFU
+	// TwoQueue is the full 2Q algorithm: new entries go through a FIFO queue
+	// and are promoted to the main LRU queue only if they are requested again
+	// shortly after eviction, so one-off scans do not flush the hot set.
+	TwoQueue
+	// ARC is the Adaptive Replacement Cache, which balances recency and frequency
+	// using the history of recently evicted keys.
+	ARC
+)
+
+func (p Policy) String() string {
+	switch p {
+	case LRU:
+		return "LRU"
+	case LFU:
+		return "LFU"
+	case TwoQueue:
+		return "2Q"
+	case ARC:
+		return "ARC"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+// evictionQueue keeps cache entries in the order defined by a policy.
+// The cache holds pointers to the list items returned by push,
+// so a queue must relink items instead of re-creating them.
+type evictionQueue[K comparable, V any] interface {
+	Len() int
+	// push adds a new entry.
+	push(v cacheItem[K, V]) *TypedListItem[cacheItem[K, V]]
+	// touch records a hit on an entry.
+	touch(i *TypedListItem[cacheItem[K, V]])
+	// remove unlinks an entry the cache drops for its own reasons.
+	remove(i *TypedListItem[cacheItem[K, V]])
+	// evict unlinks and returns the entry to drop to make room for incoming.
+	evict(incoming K) *TypedListItem[cacheItem[K, V]]
+	// shrink unlinks and returns the entry to drop when the capacity goes down.
+	shrink() *TypedListItem[cacheItem[K, V]]
+	// resize adapts the queue to a new capacity in entries.
+	resize(capacity int)
+	// walk visits entries starting from the next eviction candidate.
+	walk(fn func(i *TypedListItem[cacheItem[K, V]]))
+}
+
+func newEvictionQueue[K comparable, V any](policy Policy, capacity int) evictionQueue[K, V] {
+	switch policy {
+	case LFU:
+		return newLFUQueue[K, V]()
+	case TwoQueue:
+		return newTwoQueue[K, V](capacity)
+	case ARC:
+		return newARCQueue[K, V](capacity)
+	case LRU:
+		fallthrough
+	default:
+		return newLRUQueue[K, V]()
+	}
+}
+
+type lruQueue[K comparable, V any] struct {
+	list *TypedList[cacheItem[K, V]]
+}
+
+func newLRUQueue[K comparable, V any]() *lruQueue[K, V] {
+	return &lruQueue[K, V]{list: NewTypedList[cacheItem[K, V]]()}
+}
+
+func (q *lruQueue[K, V]) Len() int {
+	return q.list.Len()
+}
+
+func (q *lruQueue[K, V]) push(v cacheItem[K, V]) *TypedListItem[cacheItem[K, V]] {
+	return q.list.PushFront(v)
+}
+
+func (q *lruQueue[K, V]) touch(i *TypedListItem[cacheItem[K, V]]) {
+	q.list.MoveToFront(i)
+}
+
+func (q *lruQueue[K, V]) remove(i *TypedListItem[cacheItem[K, V]]) {
+	q.list.Remove(i)
+}
+
+func (q *lruQueue[K, V]) evict(K) *TypedListItem[cacheItem[K, V]] {
+	return q.shrink()
+}
+
+func (q *lruQueue[K, V]) shrink() *TypedListItem[cacheItem[K, V]] {
+	back := q.list.Back()
+	q.list.Remove(back)
+	return back
+}
+
+func (q *lruQueue[K, V]) resize(int) {}
+
+func (q *lruQueue[K, V]) walk(fn func(i *TypedListItem[cacheItem[K, V]])) {
+	walkBackward(q.list, fn)
+}
+
+func walkBackward[T any](l *TypedList[T], fn func(i *TypedListItem[T])) {
+	for i := l.Back(); i != nil; {
+		prev := i.Prev
+		fn(i)
+		i = prev
+	}
+}
+
+// ghostList remembers keys of recently evicted entries, most recent first.
+type ghostList[K comparable] struct {
+	list  *TypedList[K]
+	items map[K]*TypedListItem[K]
+}
+
+func newGhostList[K comparable]() *ghostList[K] {
+	return &ghostList[K]{
+		list:  NewTypedList[K](),
+		items: make(map[K]*TypedListItem[K]),
+	}
+}
+
+func (g *ghostList[K]) Len() int {
+	return g.list.Len()
+}
+
+func (g *ghostList[K]) contains(key K) bool {
+	_, ok := g.items[key]
+	return ok
+}
+
+func (g *ghostList[K]) push(key K) {
+	g.items[key] = g.list.PushFront(key)
+}
+
+func (g *ghostList[K]) remove(key K) bool {
+	item, ok := g.items[key]
+	if ok {
+		g.list.Remove(item)
+		delete(g.items, key)
+	}
+	return ok
+}
+
+func (g *ghostList[K]) removeOldest() {
+	if back := g.list.Back(); back != nil {
+		g.remove(back.Value)
+	}
+}
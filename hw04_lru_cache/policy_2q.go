@@ -0,0 +1,89 @@
+package hw04lrucache
+
+const (
+	segmentRecent = iota
+	segmentFrequent
+)
+
+// twoQueue implements 2Q as described by Johnson and Shasha:
+// recent is the FIFO A1in, frequent is the LRU Am and ghosts is A1out.
+type twoQueue[K comparable, V any] struct {
+	recent     *TypedList[cacheItem[K, V]]
+	frequent   *TypedList[cacheItem[K, V]]
+	ghosts     *ghostList[K]
+	recentSize int
+	ghostSize  int
+}
+
+func newTwoQueue[K comparable, V any](capacity int) *twoQueue[K, V] {
+	q := &twoQueue[K, V]{
+		recent:   NewTypedList[cacheItem[K, V]](),
+		frequent: NewTypedList[cacheItem[K, V]](),
+		ghosts:   newGhostList[K](),
+	}
+	q.resize(capacity)
+	return q
+}
+
+func (q *twoQueue[K, V]) Len() int {
+	return q.recent.Len() + q.frequent.Len()
+}
+
+func (q *twoQueue[K, V]) push(v cacheItem[K, V]) *TypedListItem[cacheItem[K, V]] {
+	if q.ghosts.remove(v.key) {
+		v.segment = segmentFrequent
+		return q.frequent.PushFront(v)
+	}
+	if q.ghosts.Len() > q.ghostSize {
+		q.ghosts.removeOldest()
+	}
+	v.segment = segmentRecent
+	return q.recent.PushFront(v)
+}
+
+func (q *twoQueue[K, V]) touch(i *TypedListItem[cacheItem[K, V]]) {
+	// Hits in the FIFO queue are ignored on purpose: they are likely correlated references.
+	if i.Value.segment == segmentFrequent {
+		q.frequent.MoveToFront(i)
+	}
+}
+
+func (q *twoQueue[K, V]) remove(i *TypedListItem[cacheItem[K, V]]) {
+	q.segment(i).Remove(i)
+}
+
+func (q *twoQueue[K, V]) evict(K) *TypedListItem[cacheItem[K, V]] {
+	return q.shrink()
+}
+
+func (q *twoQueue[K, V]) shrink() *TypedListItem[cacheItem[K, V]] {
+	if q.recent.Len() >= q.recentSize || q.frequent.Len() == 0 {
+		victim := q.recent.Back()
+		q.recent.Remove(victim)
+		q.ghosts.push(victim.Value.key)
+		return victim
+	}
+	victim := q.frequent.Back()
+	q.frequent.Remove(victim)
+	return victim
+}
+
+func (q *twoQueue[K, V]) resize(capacity int) {
+	q.recentSize = max(capacity/4, 1)
+	q.ghostSize = max(capacity/2, 1)
+	for q.ghosts.Len() > q.ghostSize {
+		q.ghosts.removeOldest()
+	}
+}
+
+func (q *twoQueue[K, V]) walk(fn func(i *TypedListItem[cacheItem[K, V]])) {
+	walkBackward(q.recent, fn)
+	walkBackward(q.frequent, fn)
+}
+
+func (q *twoQueue[K, V]) segment(i *TypedListItem[cacheItem[K, V]]) *TypedList[cacheItem[K, V]] {
+	if i.Value.segment == segmentFrequent {
+		return q.frequent
+	}
+	return q.recent
+}
@@ -0,0 +1,127 @@
+package hw04lrucache
+
+// arcQueue implements ARC as described by Megiddo and Modha.
+// recent and frequent are T1 and T2, recentGhosts and frequentGhosts are B1 and B2,
+// target is the adaptive size p of T1.
+type arcQueue[K comparable, V any] struct {
+	recent         *TypedList[cacheItem[K, V]]
+	frequent       *TypedList[cacheItem[K, V]]
+	recentGhosts   *ghostList[K]
+	frequentGhosts *ghostList[K]
+	capacity       int
+	target         int
+	adapted        bool
+	adaptedKey     K
+}
+
+func newARCQueue[K comparable, V any](capacity int) *arcQueue[K, V] {
+	return &arcQueue[K, V]{
+		recent:         NewTypedList[cacheItem[K, V]](),
+		frequent:       NewTypedList[cacheItem[K, V]](),
+		recentGhosts:   newGhostList[K](),
+		frequentGhosts: newGhostList[K](),
+		capacity:       capacity,
+	}
+}
+
+func (q *arcQueue[K, V]) Len() int {
+	return q.recent.Len() + q.frequent.Len()
+}
+
+func (q *arcQueue[K, V]) push(v cacheItem[K, V]) *TypedListItem[cacheItem[K, V]] {
+	// The target is adapted by evict when the cache is full, and here otherwise.
+	if !q.adapted || q.adaptedKey != v.key {
+		q.adapt(v.key)
+	}
+	q.adapted = false
+
+	if q.recentGhosts.remove(v.key) || q.frequentGhosts.remove(v.key) {
+		v.segment = segmentFrequent
+		return q.frequent.PushFront(v)
+	}
+
+	v.segment = segmentRecent
+	item := q.recent.PushFront(v)
+	if q.recent.Len()+q.recentGhosts.Len() > q.capacity {
+		q.recentGhosts.removeOldest()
+	}
+	if q.Len()+q.recentGhosts.Len()+q.frequentGhosts.Len() > 2*q.capacity {
+		q.frequentGhosts.removeOldest()
+	}
+	return item
+}
+
+func (q *arcQueue[K, V]) touch(i *TypedListItem[cacheItem[K, V]]) {
+	q.segment(i).Remove(i)
+	i.Value.segment = segmentFrequent
+	q.frequent.linkFront(i)
+}
+
+func (q *arcQueue[K, V]) remove(i *TypedListItem[cacheItem[K, V]]) {
+	q.segment(i).Remove(i)
+}
+
+func (q *arcQueue[K, V]) evict(incoming K) *TypedListItem[cacheItem[K, V]] {
+	if !q.adapted || q.adaptedKey != incoming {
+		q.adapt(incoming)
+		q.adapted, q.adaptedKey = true, incoming
+	}
+	return q.replace(q.frequentGhosts.contains(incoming))
+}
+
+func (q *arcQueue[K, V]) shrink() *TypedListItem[cacheItem[K, V]] {
+	return q.replace(false)
+}
+
+func (q *arcQueue[K, V]) resize(capacity int) {
+	q.capacity = capacity
+	q.target = min(q.target, capacity)
+	for q.recentGhosts.Len() > 0 && q.recent.Len()+q.recentGhosts.Len() > capacity {
+		q.recentGhosts.removeOldest()
+	}
+	for q.frequentGhosts.Len() > 0 && q.Len()+q.recentGhosts.Len()+q.frequentGhosts.Len() > 2*capacity {
+		q.frequentGhosts.removeOldest()
+	}
+}
+
+// replace is the REPLACE subroutine of ARC: it evicts from T1 or T2 depending on the target.
+func (q *arcQueue[K, V]) replace(incomingInFrequentGhosts bool) *TypedListItem[cacheItem[K, V]] {
+	recentLen := q.recent.Len()
+	fromRecent := recentLen > 0 &&
+		(recentLen > q.target || (recentLen == q.target && incomingInFrequentGhosts))
+	if fromRecent || q.frequent.Len() == 0 {
+		victim := q.recent.Back()
+		q.recent.Remove(victim)
+		q.recentGhosts.push(victim.Value.key)
+		return victim
+	}
+
+	victim := q.frequent.Back()
+	q.frequent.Remove(victim)
+	q.frequentGhosts.push(victim.Value.key)
+	return victim
+}
+
+func (q *arcQueue[K, V]) walk(fn func(i *TypedListItem[cacheItem[K, V]])) {
+	walkBackward(q.recent, fn)
+	walkBackward(q.frequent, fn)
+}
+
+// adapt moves the target towards the list whose ghost was hit.
+func (q *arcQueue[K, V]) adapt(key K) {
+	switch {
+	case q.recentGhosts.contains(key):
+		delta := max(q.frequentGhosts.Len()/q.recentGhosts.Len(), 1)
+		q.target = min(q.target+delta, q.capacity)
+	case q.frequentGhosts.contains(key):
+		delta := max(q.recentGhosts.Len()/q.frequentGhosts.Len(), 1)
+		q.target = max(q.target-delta, 0)
+	}
+}
+
+func (q *arcQueue[K, V]) segment(i *TypedListItem[cacheItem[K, V]]) *TypedList[cacheItem[K, V]] {
+	if i.Value.segment == segmentFrequent {
+		return q.frequent
+	}
+	return q.recent
+}
@@ -0,0 +1,92 @@
+package hw04lrucache
+
+import "sort"
+
+// lfuQueue keeps a list per use count. Entries within a list are in LRU order.
+type lfuQueue[K comparable, V any] struct {
+	buckets map[int]*TypedList[cacheItem[K, V]]
+	minFreq int
+	len     int
+}
+
+func newLFUQueue[K comparable, V any]() *lfuQueue[K, V] {
+	return &lfuQueue[K, V]{
+		buckets: make(map[int]*TypedList[cacheItem[K, V]]),
+	}
+}
+
+func (q *lfuQueue[K, V]) Len() int {
+	return q.len
+}
+
+func (q *lfuQueue[K, V]) push(v cacheItem[K, V]) *TypedListItem[cacheItem[K, V]] {
+	v.freq = 1
+	q.minFreq = 1
+	q.len++
+	return q.bucket(1).PushFront(v)
+}
+
+func (q *lfuQueue[K, V]) touch(i *TypedListItem[cacheItem[K, V]]) {
+	freq := i.Value.freq
+	q.unlink(i)
+	if q.minFreq == freq && q.buckets[freq] == nil {
+		q.minFreq = freq + 1
+	}
+	i.Value.freq++
+	q.bucket(i.Value.freq).linkFront(i)
+}
+
+func (q *lfuQueue[K, V]) remove(i *TypedListItem[cacheItem[K, V]]) {
+	q.unlink(i)
+	q.len--
+}
+
+func (q *lfuQueue[K, V]) evict(K) *TypedListItem[cacheItem[K, V]] {
+	return q.shrink()
+}
+
+func (q *lfuQueue[K, V]) shrink() *TypedListItem[cacheItem[K, V]] {
+	bucket, ok := q.buckets[q.minFreq]
+	if !ok {
+		// The least used bucket was emptied by remove, look for the next one.
+		q.minFreq = q.freqs()[0]
+		bucket = q.buckets[q.minFreq]
+	}
+	victim := bucket.Back()
+	q.remove(victim)
+	return victim
+}
+
+func (q *lfuQueue[K, V]) resize(int) {}
+
+func (q *lfuQueue[K, V]) walk(fn func(i *TypedListItem[cacheItem[K, V]])) {
+	for _, freq := range q.freqs() {
+		walkBackward(q.buckets[freq], fn)
+	}
+}
+
+func (q *lfuQueue[K, V]) bucket(freq int) *TypedList[cacheItem[K, V]] {
+	bucket, ok := q.buckets[freq]
+	if !ok {
+		bucket = NewTypedList[cacheItem[K, V]]()
+		q.buckets[freq] = bucket
+	}
+	return bucket
+}
+
+func (q *lfuQueue[K, V]) unlink(i *TypedListItem[cacheItem[K, V]]) {
+	bucket := q.buckets[i.Value.freq]
+	bucket.Remove(i)
+	if bucket.Len() == 0 {
+		delete(q.buckets, i.Value.freq)
+	}
+}
+
+func (q *lfuQueue[K, V]) freqs() []int {
+	freqs := make([]int, 0, len(q.buckets))
+	for freq := range q.buckets {
+		freqs = append(freqs, freq)
+	}
+	sort.Ints(freqs)
+	return freqs
+}
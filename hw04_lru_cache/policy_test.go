@@ -0,0 +1,229 @@
+package hw04lrucache
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var policies = []Policy{LRU, LFU, TwoQueue, ARC}
+
+func TestPolicies(t *testing.T) {
+	for _, policy := range policies {
+		t.Run(policy.String(), func(t *testing.T) {
+			c := NewCache(3, WithPolicy(policy))
+
+			for i := 0; i < 10; i++ {
+				key := Key(strconv.Itoa(i))
+				require.False(t, c.Set(key, i))
+
+				val, ok := c.Get(key)
+				require.True(t, ok)
+				require.Equal(t, i, val)
+
+				require.True(t, c.Set(key, i*10))
+				require.LessOrEqual(t, c.Stats().Size, 3)
+			}
+
+			records := recordEvictions(c)
+			c.Clear()
+			require.Len(t, *records, 3)
+			require.Equal(t, 0, c.Stats().Size)
+
+			c.Set("a", 1)
+			val, ok := c.Get("a")
+			require.True(t, ok)
+			require.Equal(t, 1, val)
+		})
+	}
+}
+
+func TestLFU(t *testing.T) {
+	t.Run("evicts least frequently used", func(t *testing.T) {
+		c := NewCache(2, WithPolicy(LFU))
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Get("a")
+		c.Get("b")
+		c.Set("c", 3) // "b" should be evicted
+
+		_, ok := c.Get("b")
+		require.False(t, ok)
+
+		_, ok = c.Get("a")
+		require.True(t, ok)
+	})
+
+	t.Run("least recently used among equals", func(t *testing.T) {
+		c := NewCache(2, WithPolicy(LFU))
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("b")
+		c.Get("a")
+		c.Set("c", 3) // "b" should be evicted
+
+		_, ok := c.Get("b")
+		require.False(t, ok)
+
+		_, ok = c.Get("a")
+		require.True(t, ok)
+	})
+
+	t.Run("eviction after removal of least used", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(2, WithPolicy(LFU), WithClock(clock))
+
+		c.SetWithTTL("a", 1, time.Second)
+		c.Set("b", 2)
+		c.Get("b")
+		clock.Advance(time.Second)
+		c.Get("a") // expired, the bucket with frequency 1 is gone
+
+		c.Set("c", 3)
+		c.Set("d", 4) // "c" should be evicted
+
+		_, ok := c.Get("c")
+		require.False(t, ok)
+
+		_, ok = c.Get("b")
+		require.True(t, ok)
+	})
+}
+
+func TestTwoQueue(t *testing.T) {
+	c := NewCache(4, WithPolicy(TwoQueue))
+
+	for _, key := range []Key{"a", "b", "c", "d", "e", "f"} {
+		c.Set(key, key)
+	} // "a" and "b" are remembered as recently evicted
+
+	c.Set("a", "a") // goes straight to the main queue
+
+	for i := 0; i < 10; i++ {
+		c.Set(Key("scan"+strconv.Itoa(i)), i)
+	}
+
+	_, ok := c.Get("a")
+	require.True(t, ok)
+}
+
+func TestARC(t *testing.T) {
+	t.Run("frequently used entry survives scan", func(t *testing.T) {
+		c := NewCache(4, WithPolicy(ARC))
+
+		c.Set("a", 1)
+		c.Get("a")
+
+		for i := 0; i < 10; i++ {
+			c.Set(Key("scan"+strconv.Itoa(i)), i)
+		}
+
+		_, ok := c.Get("a")
+		require.True(t, ok)
+	})
+
+	t.Run("ghost hit adapts target", func(t *testing.T) {
+		c := NewCache(2, WithPolicy(ARC))
+		queue := c.(*lruCache[Key, any]).queue.(*arcQueue[Key, any])
+
+		c.Set("a", 1)
+		c.Get("a")
+		c.Set("b", 2)
+		c.Set("c", 3) // "b" is evicted to the recent ghosts
+		require.True(t, queue.recentGhosts.contains("b"))
+		require.Equal(t, 0, queue.target)
+
+		c.Set("b", 2) // "a" is evicted to the frequent ghosts
+		require.Equal(t, 1, queue.target)
+		require.True(t, queue.frequentGhosts.contains("a"))
+
+		_, ok := c.Get("b")
+		require.True(t, ok)
+	})
+}
+
+func TestPolicyTraces(t *testing.T) {
+	tests := []struct {
+		trace  string
+		policy Policy
+	}{
+		// Loops larger than the cache are the worst case for LRU.
+		{trace: "loop", policy: TwoQueue},
+		{trace: "scan", policy: LFU},
+		{trace: "scan", policy: ARC},
+		{trace: "zipf", policy: LFU},
+		{trace: "zipf", policy: TwoQueue},
+		{trace: "zipf", policy: ARC},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.trace+"/"+tc.policy.String(), func(t *testing.T) {
+			keys := loadTrace(t, filepath.Join("testdata", tc.trace+".trace"))
+
+			lru := replayTrace(NewCache(100, WithPolicy(LRU)), keys)
+			ratio := replayTrace(NewCache(100, WithPolicy(tc.policy)), keys)
+			require.Greater(t, ratio, lru)
+		})
+	}
+}
+
+//go:generate go run ./testdata/gentrace
+
+// BenchmarkPolicyHitRate replays key traces from testdata and reports the hit ratio of every policy.
+// The traces are synthetic, they are made by testdata/gentrace with a fixed seed.
+func BenchmarkPolicyHitRate(b *testing.B) {
+	traces, err := filepath.Glob(filepath.Join("testdata", "*.trace"))
+	require.NoError(b, err)
+
+	for _, trace := range traces {
+		keys := loadTrace(b, trace)
+		name := strings.TrimSuffix(filepath.Base(trace), ".trace")
+
+		for _, policy := range policies {
+			b.Run(name+"/"+policy.String(), func(b *testing.B) {
+				var ratio float64
+				for i := 0; i < b.N; i++ {
+					ratio = replayTrace(NewCache(100, WithPolicy(policy)), keys)
+				}
+				b.ReportMetric(100*ratio, "hit%")
+			})
+		}
+	}
+}
+
+func loadTrace(tb testing.TB, path string) []Key {
+	tb.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(tb, err)
+	defer f.Close()
+
+	var keys []Key
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			keys = append(keys, Key(line))
+		}
+	}
+	require.NoError(tb, scanner.Err())
+	return keys
+}
+
+// replayTrace works the way a read-through cache is used: every miss is followed by Set.
+func replayTrace(c Cache, keys []Key) float64 {
+	for _, key := range keys {
+		if _, ok := c.Get(key); !ok {
+			c.Set(key, struct{}{})
+		}
+	}
+	return c.Stats().HitRatio()
+}
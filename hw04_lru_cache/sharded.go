@@ -0,0 +1,167 @@
+package hw04lrucache
+
+import (
+	"io"
+	"time"
+)
+
+// shardedCache spreads keys over independently locked lruCache shards,
+// so goroutines working with different keys rarely contend for a lock.
+// LRU order is kept per shard, not across the whole cache.
+type shardedCache struct {
+	shards  []*lruCache[Key, any]
+	codec   Codec
+	sweeper *sweeper
+}
+
+// NewShardedCache creates a goroutine-safe cache split into the given number of shards.
+// The capacity is distributed between shards as evenly as possible.
+// There are no more shards than capacity, so every shard can hold at least one item.
+func NewShardedCache(capacity, shards int, opts ...Option) Cache {
+	shards = max(min(shards, capacity), 1)
+
+	cfg := newConfig(opts)
+	c := &shardedCache{
+		shards: make([]*lruCache[Key, any], shards),
+		codec:  cfg.codec,
+	}
+	for i := range c.shards {
+		shardCapacity := c.shardCapacity(i, capacity)
+		c.shards[i] = newLRUCache[Key, any](shardCapacity, int64(shardCapacity), nil, cfg)
+	}
+	c.sweeper = startSweeper(cfg.sweepInterval, c.removeExpired)
+	return c
+}
+
+func (c *shardedCache) Set(key Key, value any) bool {
+	return c.shard(key).Set(key, value)
+}
+
+func (c *shardedCache) SetWithTTL(key Key, value any, ttl time.Duration) bool {
+	return c.shard(key).SetWithTTL(key, value, ttl)
+}
+
+func (c *shardedCache) TrySet(key Key, value any) (bool, error) {
+	return c.shard(key).TrySet(key, value)
+}
+
+func (c *shardedCache) Get(key Key) (any, bool) {
+	return c.shard(key).Get(key)
+}
+
+func (c *shardedCache) Peek(key Key) (any, bool) {
+	return c.shard(key).Peek(key)
+}
+
+func (c *shardedCache) Delete(key Key) bool {
+	return c.shard(key).Delete(key)
+}
+
+// Keys returns keys of all shards. The recency order is kept within each shard only.
+func (c *shardedCache) Keys() []Key {
+	var keys []Key
+	for _, shard := range c.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	return keys
+}
+
+func (c *shardedCache) Len() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// Resize keeps the number of shards, so shards left without capacity
+// when it is less than the number of shards do not keep their keys.
+func (c *shardedCache) Resize(capacity int) {
+	capacity = max(capacity, 0)
+	for i, shard := range c.shards {
+		shard.Resize(c.shardCapacity(i, capacity))
+	}
+}
+
+func (c *shardedCache) Clear() {
+	for _, shard := range c.shards {
+		shard.Clear()
+	}
+}
+
+func (c *shardedCache) Close() {
+	c.sweeper.Close()
+}
+
+func (c *shardedCache) OnEvict(fn func(key Key, value any, reason EvictReason)) {
+	for _, shard := range c.shards {
+		shard.OnEvict(fn)
+	}
+}
+
+func (c *shardedCache) Stats() Stats {
+	var stats Stats
+	for _, shard := range c.shards {
+		stats = stats.add(shard.Stats())
+	}
+	return stats
+}
+
+// Save writes entries of all shards. The recency order is kept within each shard.
+func (c *shardedCache) Save(w io.Writer) error {
+	var entries []snapshotEntry[Key, any]
+	for _, shard := range c.shards {
+		entries = append(entries, shard.snapshot()...)
+	}
+	return writeSnapshot(c.codec, w, entries)
+}
+
+func (c *shardedCache) Load(r io.Reader) error {
+	entries, err := readSnapshot[Key, any](c.codec, r)
+	if err != nil {
+		return err
+	}
+
+	perShard := make(map[*lruCache[Key, any]][]snapshotEntry[Key, any], len(c.shards))
+	for _, e := range entries {
+		shard := c.shard(e.Key)
+		perShard[shard] = append(perShard[shard], e)
+	}
+	for shard, entries := range perShard {
+		shard.restore(entries)
+	}
+	return nil
+}
+
+func (c *shardedCache) removeExpired() {
+	for _, shard := range c.shards {
+		shard.removeExpired()
+	}
+}
+
+func (c *shardedCache) shardCapacity(i, capacity int) int {
+	shardCapacity := capacity / len(c.shards)
+	if i < capacity%len(c.shards) {
+		shardCapacity++
+	}
+	return shardCapacity
+}
+
+func (c *shardedCache) shard(key Key) *lruCache[Key, any] {
+	return c.shards[hashKey(key)%uint64(len(c.shards))]
+}
+
+// hashKey is an allocation-free FNV-1a.
+func hashKey(key Key) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	h := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime64
+	}
+	return h
+}
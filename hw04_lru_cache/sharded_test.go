@@ -0,0 +1,171 @@
+package hw04lrucache
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedCache(t *testing.T) {
+	t.Run("empty cache", func(t *testing.T) {
+		c := NewShardedCache(10, 4)
+
+		_, ok := c.Get("aaa")
+		require.False(t, ok)
+	})
+
+	t.Run("simple", func(t *testing.T) {
+		c := NewShardedCache(8, 4)
+
+		wasInCache := c.Set("aaa", 100)
+		require.False(t, wasInCache)
+
+		wasInCache = c.Set("aaa", 200)
+		require.True(t, wasInCache)
+
+		val, ok := c.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 200, val)
+	})
+
+	t.Run("capacity is distributed between shards", func(t *testing.T) {
+		c := NewShardedCache(10, 4).(*shardedCache)
+
+		total := 0
+		for _, shard := range c.shards {
+			require.GreaterOrEqual(t, shard.capacity, 2)
+			require.LessOrEqual(t, shard.capacity, 3)
+			total += shard.capacity
+		}
+		require.Equal(t, 10, total)
+	})
+
+	t.Run("no more shards than capacity", func(t *testing.T) {
+		c := NewShardedCache(4, 16).(*shardedCache)
+		require.Len(t, c.shards, 4)
+
+		for i := 0; i < 100; i++ {
+			key := Key(strconv.Itoa(i))
+			c.Set(key, i)
+			val, ok := c.Get(key)
+			require.True(t, ok)
+			require.Equal(t, i, val)
+		}
+		require.Equal(t, 4, c.Len())
+
+		require.Len(t, NewShardedCache(0, 16).(*shardedCache).shards, 1)
+	})
+
+	t.Run("lru order within a shard", func(t *testing.T) {
+		c := NewShardedCache(3, 1)
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Set("c", 3)
+		c.Get("a")
+		c.Set("d", 4) // "b" should be evicted
+
+		_, ok := c.Get("b")
+		require.False(t, ok)
+
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+	})
+
+	t.Run("same key always goes to the same shard", func(t *testing.T) {
+		c := NewShardedCache(100, 8).(*shardedCache)
+
+		for i := 0; i < 100; i++ {
+			key := Key(strconv.Itoa(i))
+			require.Same(t, c.shard(key), c.shard(key))
+		}
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		c := NewShardedCache(10, 4).(*shardedCache)
+
+		for i := 0; i < 10; i++ {
+			c.Set(Key(strconv.Itoa(i)), i)
+		}
+		c.Clear()
+
+		for _, shard := range c.shards {
+			require.Equal(t, 0, shard.queue.Len())
+			require.Equal(t, 0, len(shard.items))
+		}
+	})
+}
+
+func TestShardedCacheMultithreading(t *testing.T) {
+	c := NewShardedCache(100, 8)
+	wg := &sync.WaitGroup{}
+
+	for g := 0; g < 4; g++ {
+		wg.Add(3)
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100_000; i++ {
+				c.Set(Key(strconv.Itoa(i)), i)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100_000; i++ {
+				key := rand.Intn(100_000)
+				if val, ok := c.Get(Key(strconv.Itoa(key))); ok && val != key {
+					t.Errorf("got %v for key %d", val, key)
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.Clear()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func BenchmarkCacheParallel(b *testing.B) {
+	const capacity = 1024
+
+	keys := make([]Key, 4*capacity)
+	for i := range keys {
+		keys[i] = Key(strconv.Itoa(i))
+	}
+
+	caches := []struct {
+		name  string
+		cache Cache
+	}{
+		{name: "single mutex", cache: NewCache(capacity)},
+		{name: "sharded 4", cache: NewShardedCache(capacity, 4)},
+		{name: "sharded 16", cache: NewShardedCache(capacity, 16)},
+		{name: "sharded 64", cache: NewShardedCache(capacity, 64)},
+	}
+
+	for _, tc := range caches {
+		b.Run(tc.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewSource(rand.Int63())) //nolint:gosec
+				for pb.Next() {
+					key := keys[r.Intn(len(keys))]
+					if r.Intn(4) == 0 {
+						tc.cache.Set(key, key)
+					} else {
+						tc.cache.Get(key)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package hw04lrucache
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const snapshotVersion = 1
+
+var (
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+	ErrSnapshotCorrupt = errors.New("corrupt snapshot")
+)
+
+// Codec turns a stream of snapshot records into bytes and back.
+// *gob.Encoder, *json.Encoder and friends satisfy Encoder and Decoder.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+type Encoder interface {
+	Encode(v any) error
+}
+
+type Decoder interface {
+	Decode(v any) error
+}
+
+// GobCodec is the default codec. Concrete types stored in interface values,
+// e.g. in Cache, must be registered with gob.Register.
+type GobCodec struct{}
+
+func (GobCodec) NewEncoder(w io.Writer) Encoder {
+	return gob.NewEncoder(w)
+}
+
+func (GobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+type snapshotHeader struct {
+	Version int
+	Len     int
+}
+
+type snapshotEntry[K comparable, V any] struct {
+	Key       K
+	Value     V
+	ExpiresAt time.Time
+}
+
+// Save writes entries that have not expired, from the least to the most recently used.
+func (c *lruCache[K, V]) Save(w io.Writer) error {
+	return writeSnapshot(c.codec, w, c.snapshot())
+}
+
+// Load adds entries written by Save as if they were set in the saved order,
+// so the most recently used entry of the saved cache becomes the most recent one here.
+// Entries that have expired since or do not fit into the cache are skipped.
+// Nothing is added if the snapshot cannot be read.
+func (c *lruCache[K, V]) Load(r io.Reader) error {
+	entries, err := readSnapshot[K, V](c.codec, r)
+	if err != nil {
+		return err
+	}
+	c.restore(entries)
+	return nil
+}
+
+func (c *lruCache[K, V]) snapshot() []snapshotEntry[K, V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	entries := make([]snapshotEntry[K, V], 0, c.queue.Len())
+	c.queue.walk(func(item *TypedListItem[cacheItem[K, V]]) {
+		if !expired(item.Value.expiresAt, now) {
+			entries = append(entries, snapshotEntry[K, V]{item.Value.key, item.Value.value, item.Value.expiresAt})
+		}
+	})
+	return entries
+}
+
+func (c *lruCache[K, V]) restore(entries []snapshotEntry[K, V]) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	now := c.clock.Now()
+	for _, e := range entries {
+		if !expired(e.ExpiresAt, now) {
+			// Entries too large for this cache are skipped.
+			_, _ = c.put(e.Key, e.Value, e.ExpiresAt)
+		}
+	}
+}
+
+func writeSnapshot[K comparable, V any](codec Codec, w io.Writer, entries []snapshotEntry[K, V]) error {
+	enc := codec.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion, Len: len(entries)}); err != nil {
+		return fmt.Errorf("write snapshot header: %w", err)
+	}
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			return fmt.Errorf("write snapshot entry %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func readSnapshot[K comparable, V any](codec Codec, r io.Reader) ([]snapshotEntry[K, V], error) {
+	dec := codec.NewDecoder(r)
+
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("read snapshot header: %w", err)
+	}
+	if header.Version != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, header.Version)
+	}
+
+	if header.Len < 0 {
+		return nil, fmt.Errorf("%w: negative length %d", ErrSnapshotCorrupt, header.Len)
+	}
+
+	// The length is not trusted for allocation, a snapshot claiming too many entries fails on its end.
+	var entries []snapshotEntry[K, V]
+	for i := 0; i < header.Len; i++ {
+		var e snapshotEntry[K, V]
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("read snapshot entry %d: %w", i, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
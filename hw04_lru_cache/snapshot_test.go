@@ -0,0 +1,181 @@
+package hw04lrucache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+type point struct {
+	X, Y int
+}
+
+func queueKeys[V any](c TypedCache[Key, V]) []Key {
+	var keys []Key
+	c.(*lruCache[Key, V]).queue.walk(func(item *TypedListItem[cacheItem[Key, V]]) {
+		keys = append([]Key{item.Value.key}, keys...)
+	})
+	return keys
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Run("keeps recency order", func(t *testing.T) {
+		c := NewCache(5)
+		for i := 0; i < 5; i++ {
+			c.Set(Key(strconv.Itoa(i)), i)
+		}
+		c.Get("1")
+		c.Set("3", 30)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Save(buf))
+
+		restored := NewCache(5)
+		require.NoError(t, restored.Load(buf))
+
+		require.Equal(t, []Key{"3", "1", "4", "2", "0"}, queueKeys(restored))
+		val, ok := restored.Get("3")
+		require.True(t, ok)
+		require.Equal(t, 30, val)
+	})
+
+	t.Run("smaller cache keeps most recent entries", func(t *testing.T) {
+		c := NewCache(5)
+		for i := 0; i < 5; i++ {
+			c.Set(Key(strconv.Itoa(i)), i)
+		}
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Save(buf))
+
+		restored := NewCache(2)
+		require.NoError(t, restored.Load(buf))
+		require.Equal(t, []Key{"4", "3"}, queueKeys(restored))
+	})
+
+	t.Run("expiration is kept", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, WithClock(clock))
+		c.SetWithTTL("short", 1, time.Second)
+		c.SetWithTTL("long", 2, time.Minute)
+		c.Set("forever", 3)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Save(buf))
+
+		clock.Advance(time.Second)
+		restored := NewCache(5, WithClock(clock))
+		require.NoError(t, restored.Load(buf))
+		require.Equal(t, []Key{"forever", "long"}, queueKeys(restored))
+
+		clock.Advance(time.Minute)
+		_, ok := restored.Get("long")
+		require.False(t, ok)
+		_, ok = restored.Get("forever")
+		require.True(t, ok)
+	})
+
+	t.Run("custom codec", func(t *testing.T) {
+		c := NewTypedCache[string, point](3, WithCodec(jsonCodec{}))
+		c.Set("a", point{1, 2})
+		c.Set("b", point{3, 4})
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Save(buf))
+		require.Contains(t, buf.String(), `"Key":"a"`)
+
+		restored := NewTypedCache[string, point](3, WithCodec(jsonCodec{}))
+		require.NoError(t, restored.Load(buf))
+
+		val, ok := restored.Get("b")
+		require.True(t, ok)
+		require.Equal(t, point{3, 4}, val)
+	})
+
+	t.Run("registered types in interface values", func(t *testing.T) {
+		gob.Register(point{})
+
+		c := NewCache(3)
+		c.Set("a", point{1, 2})
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Save(buf))
+
+		restored := NewCache(3)
+		require.NoError(t, restored.Load(buf))
+
+		val, ok := restored.Get("a")
+		require.True(t, ok)
+		require.Equal(t, point{1, 2}, val)
+	})
+
+	t.Run("broken snapshot adds nothing", func(t *testing.T) {
+		c := NewCache(3)
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Save(buf))
+
+		restored := NewCache(3)
+		err := restored.Load(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+		require.Error(t, err)
+		require.Equal(t, 0, restored.Stats().Size)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, gob.NewEncoder(buf).Encode(snapshotHeader{Version: 100}))
+
+		err := NewCache(3).Load(buf)
+		require.ErrorIs(t, err, ErrSnapshotVersion)
+	})
+
+	t.Run("corrupt header", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, gob.NewEncoder(buf).Encode(snapshotHeader{Version: snapshotVersion, Len: -1}))
+		require.ErrorIs(t, NewCache(3).Load(buf), ErrSnapshotCorrupt)
+
+		buf.Reset()
+		require.NoError(t, gob.NewEncoder(buf).Encode(snapshotHeader{Version: snapshotVersion, Len: 1 << 60}))
+		c := NewCache(3)
+		require.ErrorIs(t, c.Load(buf), io.EOF)
+		require.Equal(t, 0, c.Stats().Size)
+	})
+
+	t.Run("sharded cache", func(t *testing.T) {
+		c := NewShardedCache(16, 4)
+		for i := 0; i < 8; i++ {
+			c.Set(Key(strconv.Itoa(i)), i)
+		}
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.Save(buf))
+
+		restored := NewShardedCache(16, 4)
+		require.NoError(t, restored.Load(buf))
+		require.Equal(t, 8, restored.Stats().Size)
+
+		for i := 0; i < 8; i++ {
+			val, ok := restored.Get(Key(strconv.Itoa(i)))
+			require.True(t, ok)
+			require.Equal(t, i, val)
+		}
+	})
+}
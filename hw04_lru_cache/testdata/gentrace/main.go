@@ -0,0 +1,88 @@
+// Gentrace writes the synthetic key traces replayed by the policy tests and benchmarks.
+// The seed is fixed, so the traces are the same on every run.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+const (
+	seed   = 1
+	length = 2000
+)
+
+func main() {
+	dir := "testdata"
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+	}
+
+	rnd := rand.New(rand.NewSource(seed)) //nolint:gosec
+	traces := map[string]func(*rand.Rand) []string{
+		"loop": loop,
+		"scan": scan,
+		"zipf": zipf,
+	}
+	for _, name := range []string{"loop", "scan", "zipf"} {
+		if err := write(filepath.Join(dir, name+".trace"), traces[name](rnd)); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// loop cycles over a few more keys than the tested cache holds.
+func loop(*rand.Rand) []string {
+	keys := make([]string, 0, length)
+	for i := 0; len(keys) < length; i = (i + 1) % 120 {
+		keys = append(keys, fmt.Sprintf("l%d", i))
+	}
+	return keys
+}
+
+// scan mixes skewed accesses to a hot set with long runs of keys used only once.
+func scan(rnd *rand.Rand) []string {
+	hot := rand.NewZipf(rnd, 1.2, 1, 79)
+	keys := make([]string, 0, length)
+	scanned := 0
+	for len(keys) < length {
+		for i := 0; i < 300 && len(keys) < length; i++ {
+			keys = append(keys, fmt.Sprintf("h%d", hot.Uint64()))
+		}
+		for i := 0; i < 100 && len(keys) < length; i++ {
+			keys = append(keys, fmt.Sprintf("s%d", scanned))
+			scanned++
+		}
+	}
+	return keys
+}
+
+// zipf follows the Zipf distribution typical for web caches.
+func zipf(rnd *rand.Rand) []string {
+	z := rand.NewZipf(rnd, 1.1, 1, 999)
+	keys := make([]string, 0, length)
+	for len(keys) < length {
+		keys = append(keys, fmt.Sprintf("k%d", z.Uint64()))
+	}
+	return keys
+}
+
+func write(path string, keys []string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, key := range keys {
+		fmt.Fprintln(w, key)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
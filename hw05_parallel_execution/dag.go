@@ -0,0 +1,235 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCycle             = errors.New("dependency cycle")
+	ErrUnknownDependency = errors.New("unknown dependency")
+	ErrDuplicateTask     = errors.New("duplicate task name")
+)
+
+// DAGTask is a named task that starts only after all tasks it depends on have succeeded.
+type DAGTask struct {
+	Name string
+	Deps []string
+	Task ContextTask
+}
+
+// DAGReport lists task names by outcome, each list in the order tasks were given.
+type DAGReport struct {
+	Succeeded []string
+	Failed    []string
+	Skipped   []string
+	// Errors maps failed task names to their errors.
+	Errors map[string]error
+}
+
+// RunDAG runs tasks as their dependencies succeed, in opts.Workers goroutines.
+// Tasks that depend on a failed one, directly or not, are skipped, as are tasks that were not
+// started because the run stopped. The run stops just like RunContext does,
+// its error uses task indexes in the tasks slice.
+// Dependency cycles and unknown dependencies are reported before anything is run.
+func RunDAG(ctx context.Context, tasks []DAGTask, opts Options) (DAGReport, error) {
+	d, err := newDAG(tasks)
+	if err != nil {
+		return DAGReport{}, err
+	}
+
+	hooks := opts.Hooks
+	opts.Hooks.OnFinish = func(index int, duration time.Duration, err error) {
+		hooks.finish(index, duration, err)
+		d.finish(index, err)
+	}
+
+	err = run(ctx, d.next, opts)
+	return d.report(), err
+}
+
+type dagState uint8
+
+const (
+	dagPending dagState = iota
+	dagSucceeded
+	dagFailed
+	dagSkipped
+)
+
+type dag struct {
+	tasks      []DAGTask
+	dependents [][]int
+	ready      chan int
+	done       chan struct{}
+
+	mu       sync.Mutex
+	waiting  []int
+	state    []dagState
+	errors   map[string]error
+	resolved int
+}
+
+func newDAG(tasks []DAGTask) (*dag, error) {
+	indexes := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		if _, ok := indexes[task.Name]; ok {
+			return nil, fmt.Errorf("%w %q", ErrDuplicateTask, task.Name)
+		}
+		indexes[task.Name] = i
+	}
+
+	d := &dag{
+		tasks:      tasks,
+		dependents: make([][]int, len(tasks)),
+		ready:      make(chan int, len(tasks)),
+		done:       make(chan struct{}),
+		waiting:    make([]int, len(tasks)),
+		state:      make([]dagState, len(tasks)),
+		errors:     make(map[string]error),
+	}
+	for i, task := range tasks {
+		for _, dep := range task.Deps {
+			j, ok := indexes[dep]
+			if !ok {
+				return nil, fmt.Errorf("task %q: %w %q", task.Name, ErrUnknownDependency, dep)
+			}
+			d.dependents[j] = append(d.dependents[j], i)
+		}
+		d.waiting[i] = len(task.Deps)
+	}
+	if cycle := d.findCycle(); cycle != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, " -> "))
+	}
+
+	for i := range tasks {
+		if d.waiting[i] == 0 {
+			d.ready <- i
+		}
+	}
+	if len(tasks) == 0 {
+		close(d.done)
+	}
+	return d, nil
+}
+
+// findCycle returns names of the tasks on a dependency cycle, each depending on the next one
+// and the first one repeated at the end, or nil if there is none.
+func (d *dag) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make([]int, len(d.tasks))
+	var path []int
+
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		marks[i] = visiting
+		path = append(path, i)
+		for _, j := range d.dependents[i] {
+			switch marks[j] {
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			case visiting:
+				// Every task on the path depends on the one before it, so walk it backwards.
+				var cycle []string
+				for k := len(path) - 1; ; k-- {
+					cycle = append(cycle, d.tasks[path[k]].Name)
+					if path[k] == j {
+						return append(cycle, d.tasks[i].Name)
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		marks[i] = visited
+		return nil
+	}
+
+	for i := range d.tasks {
+		if marks[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// next is the source of the run: it waits for a task whose dependencies have succeeded.
+func (d *dag) next(ctx context.Context) (indexedTask, bool) {
+	select {
+	case <-ctx.Done():
+		return indexedTask{}, false
+	case <-d.done:
+		return indexedTask{}, false
+	case i := <-d.ready:
+		return indexedTask{index: i, task: d.tasks[i].Task}, true
+	}
+}
+
+// finish records the outcome of a task and makes its dependents ready or skips them.
+func (d *dag) finish(i int, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err != nil {
+		d.state[i] = dagFailed
+		d.errors[d.tasks[i].Name] = err
+		d.resolve()
+		d.skipDependents(i)
+		return
+	}
+
+	d.state[i] = dagSucceeded
+	d.resolve()
+	for _, j := range d.dependents[i] {
+		d.waiting[j]--
+		if d.waiting[j] == 0 && d.state[j] == dagPending {
+			d.ready <- j
+		}
+	}
+}
+
+func (d *dag) skipDependents(i int) {
+	for _, j := range d.dependents[i] {
+		if d.state[j] == dagPending {
+			d.state[j] = dagSkipped
+			d.resolve()
+			d.skipDependents(j)
+		}
+	}
+}
+
+func (d *dag) resolve() {
+	d.resolved++
+	if d.resolved == len(d.tasks) {
+		close(d.done)
+	}
+}
+
+func (d *dag) report() DAGReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := DAGReport{Errors: d.errors}
+	for i, task := range d.tasks {
+		switch d.state[i] {
+		case dagSucceeded:
+			report.Succeeded = append(report.Succeeded, task.Name)
+		case dagFailed:
+			report.Failed = append(report.Failed, task.Name)
+		case dagPending, dagSkipped:
+			report.Skipped = append(report.Skipped, task.Name)
+		}
+	}
+	return report
+}
@@ -0,0 +1,225 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// recordingTasks makes tasks that record the order they finish in.
+type recordingTasks struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *recordingTasks) task(name string, err error) DAGTask {
+	return DAGTask{
+		Name: name,
+		Task: func(context.Context) error {
+			time.Sleep(time.Millisecond)
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.order = append(r.order, name)
+			return err
+		},
+	}
+}
+
+func (r *recordingTasks) finishedBefore(t *testing.T, first, second string) {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	require.Less(t, indexOf(r.order, first), indexOf(r.order, second), "%s finished after %s", first, second)
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func withDeps(task DAGTask, deps ...string) DAGTask {
+	task.Deps = deps
+	return task
+}
+
+func TestRunDAG(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("tasks run after their dependencies", func(t *testing.T) {
+		r := &recordingTasks{}
+		tasks := []DAGTask{
+			withDeps(r.task("link", nil), "compile-a", "compile-b"),
+			withDeps(r.task("compile-a", nil), "generate"),
+			withDeps(r.task("compile-b", nil), "generate"),
+			r.task("generate", nil),
+			withDeps(r.task("test", nil), "link"),
+			r.task("lint", nil),
+		}
+
+		report, err := RunDAG(context.Background(), tasks, Options{Workers: 4, MaxErrors: 1})
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"link", "compile-a", "compile-b", "generate", "test", "lint"}, report.Succeeded)
+		require.Empty(t, report.Failed)
+		require.Empty(t, report.Skipped)
+		r.finishedBefore(t, "generate", "compile-a")
+		r.finishedBefore(t, "generate", "compile-b")
+		r.finishedBefore(t, "compile-a", "link")
+		r.finishedBefore(t, "compile-b", "link")
+		r.finishedBefore(t, "link", "test")
+	})
+
+	t.Run("dependents of failed task are skipped", func(t *testing.T) {
+		errCompile := errors.New("compile failed")
+		r := &recordingTasks{}
+		tasks := []DAGTask{
+			r.task("generate", nil),
+			withDeps(r.task("compile-a", errCompile), "generate"),
+			withDeps(r.task("compile-b", nil), "generate"),
+			withDeps(r.task("link", nil), "compile-a", "compile-b"),
+			withDeps(r.task("test", nil), "link"),
+			withDeps(r.task("docs", nil), "compile-b"),
+		}
+
+		report, err := RunDAG(context.Background(), tasks, Options{Workers: 2, IgnoreErrors: true})
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"generate", "compile-b", "docs"}, report.Succeeded)
+		require.Equal(t, []string{"compile-a"}, report.Failed)
+		require.Equal(t, []string{"link", "test"}, report.Skipped)
+		require.Equal(t, map[string]error{"compile-a": errCompile}, report.Errors)
+		require.NotContains(t, r.order, "link")
+		require.NotContains(t, r.order, "test")
+	})
+
+	t.Run("errors limit stops the run", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		r := &recordingTasks{}
+		tasks := []DAGTask{
+			r.task("a", errFailed),
+			withDeps(r.task("b", nil), "a"),
+			withDeps(r.task("c", nil), "a"),
+		}
+
+		report, err := RunDAG(context.Background(), tasks, Options{Workers: 2, MaxErrors: 1})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		var taskErr *TaskError
+		require.ErrorAs(t, err, &taskErr)
+		require.Equal(t, 0, taskErr.Index)
+		require.Equal(t, []string{"a"}, report.Failed)
+		require.Equal(t, []string{"b", "c"}, report.Skipped)
+	})
+
+	t.Run("cancelled run skips tasks that did not start", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		tasks := []DAGTask{
+			{Name: "a", Task: func(context.Context) error {
+				cancel()
+				return nil
+			}},
+			{Name: "b", Deps: []string{"a"}, Task: func(context.Context) error { return nil }},
+		}
+
+		report, err := RunDAG(ctx, tasks, Options{Workers: 1, MaxErrors: 1})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, []string{"a"}, report.Succeeded)
+		require.Equal(t, []string{"b"}, report.Skipped)
+	})
+
+	t.Run("user hooks are called", func(t *testing.T) {
+		r := &recordingTasks{}
+		tasks := []DAGTask{
+			r.task("a", nil),
+			withDeps(r.task("b", nil), "a"),
+		}
+
+		var mu sync.Mutex
+		var finished []int
+		_, err := RunDAG(context.Background(), tasks, Options{
+			Workers:   2,
+			MaxErrors: 1,
+			Hooks: Hooks{OnFinish: func(index int, _ time.Duration, _ error) {
+				mu.Lock()
+				defer mu.Unlock()
+				finished = append(finished, index)
+			}},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, []int{0, 1}, finished)
+	})
+
+	t.Run("empty graph", func(t *testing.T) {
+		report, err := RunDAG(context.Background(), nil, Options{Workers: 1, MaxErrors: 1})
+
+		require.NoError(t, err)
+		require.Equal(t, DAGReport{Errors: map[string]error{}}, report)
+	})
+}
+
+func TestRunDAGValidation(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	noop := func(context.Context) error {
+		require.Fail(t, "task of invalid graph was run")
+		return nil
+	}
+
+	for _, tc := range []struct {
+		name  string
+		tasks []DAGTask
+		err   error
+		msg   string
+	}{
+		{
+			name: "cycle",
+			tasks: []DAGTask{
+				{Name: "a", Task: noop},
+				{Name: "b", Deps: []string{"a", "d"}, Task: noop},
+				{Name: "c", Deps: []string{"b"}, Task: noop},
+				{Name: "d", Deps: []string{"c"}, Task: noop},
+			},
+			err: ErrCycle,
+			msg: "dependency cycle: d -> c -> b -> d",
+		},
+		{
+			name:  "self dependency",
+			tasks: []DAGTask{{Name: "a", Deps: []string{"a"}, Task: noop}},
+			err:   ErrCycle,
+			msg:   "dependency cycle: a -> a",
+		},
+		{
+			name:  "unknown dependency",
+			tasks: []DAGTask{{Name: "a", Deps: []string{"b"}, Task: noop}},
+			err:   ErrUnknownDependency,
+			msg:   `task "a": unknown dependency "b"`,
+		},
+		{
+			name:  "duplicate name",
+			tasks: []DAGTask{{Name: "a", Task: noop}, {Name: "a", Task: noop}},
+			err:   ErrDuplicateTask,
+			msg:   `duplicate task name "a"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := RunDAG(context.Background(), tc.tasks, Options{Workers: 1, MaxErrors: 1})
+
+			require.ErrorIs(t, err, tc.err)
+			require.EqualError(t, err, tc.msg)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package hw05parallelexecution
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TaskError is an error returned by the task with the given index after all its attempts.
+type TaskError struct {
+	Index    int
+	Err      error
+	Attempts int
+}
+
+func (e *TaskError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("task %d (%d attempts): %v", e.Index, e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("task %d: %v", e.Index, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// RunError is returned when a run is stopped before all tasks are done.
+// Cause is ErrErrorsLimitExceeded, ErrErrorRateExceeded or the error of the parent context,
+// Errors are all task errors ordered by task index.
+// Both the cause and the task errors are reachable with errors.Is and errors.As.
+type RunError struct {
+	Cause  error
+	Errors []*TaskError
+}
+
+func (e *RunError) Error() string {
+	if len(e.Errors) == 0 {
+		return e.Cause.Error()
+	}
+
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%v: %s", e.Cause, strings.Join(msgs, "; "))
+}
+
+func (e *RunError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors)+1)
+	errs = append(errs, e.Cause)
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// PanicError is returned in place of a task error when the task panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -0,0 +1,32 @@
+package hw05parallelexecution
+
+import "time"
+
+// Hooks observe a run. They are called from worker goroutines, so they must be safe for concurrent use.
+// Any of them may be nil.
+type Hooks struct {
+	// OnStart is called before the first attempt of a task.
+	OnStart func(index int)
+	// OnFinish is called after the last attempt of a task with the time spent on all attempts.
+	OnFinish func(index int, duration time.Duration, err error)
+	// OnAbort is called once a stopped run has waited for its running tasks, with the cause of the stop.
+	OnAbort func(cause error)
+}
+
+func (h Hooks) start(index int) {
+	if h.OnStart != nil {
+		h.OnStart(index)
+	}
+}
+
+func (h Hooks) finish(index int, duration time.Duration, err error) {
+	if h.OnFinish != nil {
+		h.OnFinish(index, duration, err)
+	}
+}
+
+func (h Hooks) abort(cause error) {
+	if h.OnAbort != nil {
+		h.OnAbort(cause)
+	}
+}
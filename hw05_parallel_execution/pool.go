@@ -0,0 +1,185 @@
+package hw05parallelexecution
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var ErrPoolClosed = errors.New("pool is closed")
+
+type PoolOptions struct {
+	Options
+	// Aging raises the priority of a queued task by one for every Aging it waits, when positive,
+	// so low priority tasks are not starved by a stream of urgent ones.
+	// With aging priorities beyond the range that fits into the queue order are treated as its bounds.
+	Aging time.Duration
+}
+
+// Pool is a long-lived worker pool that starts queued tasks by priority, higher first.
+// Tasks of the same priority start in the order they were submitted.
+// Task errors are counted just like in RunContext and are reported through the hooks,
+// where task indexes are submission numbers. The pool stops accepting tasks once it stops on errors.
+// Shutdown must be called to release its goroutines.
+type Pool struct {
+	opts  PoolOptions
+	epoch time.Time
+	wake  chan struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+
+	mu      sync.Mutex
+	queue   poolQueue
+	seq     int
+	closing bool
+	// runCtx is the context of the run, it is done as soon as the run stops.
+	runCtx context.Context
+}
+
+func NewPool(opts PoolOptions) (*Pool, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		opts:   opts,
+		epoch:  opts.Clock.Now(),
+		wake:   make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(p.done)
+		defer cancel()
+		p.err = run(ctx, p.next, opts.Options)
+
+		p.mu.Lock()
+		p.closing = true
+		p.mu.Unlock()
+	}()
+	return p, nil
+}
+
+// Submit queues task with the given priority. It returns ErrPoolClosed once the pool is shut down
+// or has stopped on errors.
+func (p *Pool) Submit(task ContextTask, priority int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The run may have stopped while its running tasks are not done yet.
+	if p.closing || p.runCtx != nil && p.runCtx.Err() != nil {
+		return ErrPoolClosed
+	}
+
+	heap.Push(&p.queue, &poolItem{task: task, key: p.key(priority), seq: p.seq})
+	p.seq++
+	p.notify()
+	return nil
+}
+
+// key orders tasks in the queue. With aging every task gains priority at the same rate,
+// so the order can be fixed at submission by trading one priority level for Aging of waiting.
+// The priority is saturated, so that the key does not overflow.
+func (p *Pool) key(priority int) int64 {
+	if p.opts.Aging <= 0 {
+		return int64(priority)
+	}
+	aging := int64(p.opts.Aging)
+	bound := math.MaxInt64 / 2 / aging
+	return min(max(int64(priority), -bound), bound)*aging - int64(p.opts.Clock.Now().Sub(p.epoch))
+}
+
+func (p *Pool) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Shutdown stops accepting tasks and waits until queued and running tasks are done.
+// If ctx is done first, running tasks are cancelled, queued ones are dropped and ctx.Err() is returned.
+// Otherwise it returns the error of the pool, which is *RunError if it has stopped on errors.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closing = true
+	p.notify()
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		p.cancel()
+		<-p.done
+		return ctx.Err()
+	}
+}
+
+// next is the source of the run: it waits for the most urgent task until the pool is shut down.
+func (p *Pool) next(ctx context.Context) (indexedTask, bool) {
+	for {
+		p.mu.Lock()
+		p.runCtx = ctx
+		if p.queue.Len() > 0 {
+			item := heap.Pop(&p.queue).(*poolItem)
+			p.mu.Unlock()
+			return indexedTask{index: item.seq, task: item.task}, true
+		}
+		closing := p.closing
+		p.mu.Unlock()
+
+		if closing {
+			return indexedTask{}, false
+		}
+		select {
+		case <-ctx.Done():
+			return indexedTask{}, false
+		case <-p.wake:
+		}
+	}
+}
+
+type poolItem struct {
+	task ContextTask
+	key  int64
+	seq  int
+}
+
+// poolQueue is a heap of tasks with the highest key on top, ties broken by submission order.
+type poolQueue []*poolItem
+
+func (q poolQueue) Len() int {
+	return len(q)
+}
+
+func (q poolQueue) Less(i, j int) bool {
+	if q[i].key != q[j].key {
+		return q[i].key > q[j].key
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q poolQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *poolQueue) Push(x any) {
+	*q = append(*q, x.(*poolItem))
+}
+
+func (q *poolQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
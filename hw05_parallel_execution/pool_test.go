@@ -0,0 +1,198 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+// blockedPool returns a pool with a single worker that is busy until the returned function is called,
+// so submitted tasks stay queued.
+func blockedPool(t *testing.T, opts PoolOptions) (*Pool, func()) {
+	t.Helper()
+
+	opts.Workers = 1
+	if opts.MaxErrors == 0 {
+		opts.IgnoreErrors = true
+	}
+	p, err := NewPool(opts)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.NoError(t, p.Submit(func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}, 0))
+	<-started
+	return p, func() { close(release) }
+}
+
+type startOrder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (o *startOrder) task(name string) ContextTask {
+	return func(context.Context) error {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.names = append(o.names, name)
+		return nil
+	}
+}
+
+func TestPool(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("higher priority starts first, ties in submission order", func(t *testing.T) {
+		p, release := blockedPool(t, PoolOptions{})
+		order := &startOrder{}
+
+		require.NoError(t, p.Submit(order.task("low"), 1))
+		require.NoError(t, p.Submit(order.task("high-1"), 10))
+		require.NoError(t, p.Submit(order.task("normal-1"), 5))
+		require.NoError(t, p.Submit(order.task("high-2"), 10))
+		require.NoError(t, p.Submit(order.task("normal-2"), 5))
+		require.NoError(t, p.Submit(order.task("negative"), -1))
+		release()
+
+		require.NoError(t, p.Shutdown(context.Background()))
+		require.Equal(t, []string{"high-1", "high-2", "normal-1", "normal-2", "low", "negative"}, order.names)
+	})
+
+	t.Run("aging lets waiting tasks go first", func(t *testing.T) {
+		clock := &fakeClock{}
+		opts := PoolOptions{Aging: time.Second}
+		opts.Clock = clock
+		p, release := blockedPool(t, opts)
+		order := &startOrder{}
+
+		require.NoError(t, p.Submit(order.task("old-low"), 0))
+		clock.After(5 * time.Second)
+		require.NoError(t, p.Submit(order.task("new-high"), 3))
+		require.NoError(t, p.Submit(order.task("new-urgent"), 6))
+		release()
+
+		require.NoError(t, p.Shutdown(context.Background()))
+		require.Equal(t, []string{"new-urgent", "old-low", "new-high"}, order.names)
+	})
+
+	t.Run("submit after shutdown", func(t *testing.T) {
+		p, err := NewPool(PoolOptions{Options: Options{Workers: 2, MaxErrors: 1}})
+		require.NoError(t, err)
+		require.NoError(t, p.Shutdown(context.Background()))
+
+		require.ErrorIs(t, p.Submit(func(context.Context) error { return nil }, 0), ErrPoolClosed)
+		require.NoError(t, p.Shutdown(context.Background()))
+	})
+
+	t.Run("shutdown waits for queued tasks", func(t *testing.T) {
+		p, err := NewPool(PoolOptions{Options: Options{Workers: 3, MaxErrors: 1}})
+		require.NoError(t, err)
+
+		order := &startOrder{}
+		for i := 0; i < 20; i++ {
+			require.NoError(t, p.Submit(order.task("task"), i))
+		}
+
+		require.NoError(t, p.Shutdown(context.Background()))
+		require.Len(t, order.names, 20)
+	})
+
+	t.Run("shutdown deadline cancels running tasks", func(t *testing.T) {
+		p, err := NewPool(PoolOptions{Options: Options{Workers: 1, MaxErrors: 1}})
+		require.NoError(t, err)
+
+		started := make(chan struct{})
+		require.NoError(t, p.Submit(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}, 0))
+		order := &startOrder{}
+		require.NoError(t, p.Submit(order.task("queued"), 0))
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+		require.Empty(t, order.names)
+	})
+
+	t.Run("pool stops on errors limit", func(t *testing.T) {
+		errTask := errors.New("failed")
+		p, release := blockedPool(t, PoolOptions{Options: Options{MaxErrors: 1}})
+		order := &startOrder{}
+
+		require.NoError(t, p.Submit(func(context.Context) error { return errTask }, 1))
+		require.NoError(t, p.Submit(order.task("after error"), 0))
+		release()
+
+		err := p.Shutdown(context.Background())
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.ErrorIs(t, err, errTask)
+		require.Empty(t, order.names)
+		require.ErrorIs(t, p.Submit(order.task("late"), 0), ErrPoolClosed)
+	})
+
+	t.Run("submit once errors limit is reached", func(t *testing.T) {
+		p, err := NewPool(PoolOptions{Options: Options{Workers: 2, MaxErrors: 1}})
+		require.NoError(t, err)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		require.NoError(t, p.Submit(func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		}, 0))
+		<-started
+		require.NoError(t, p.Submit(func(context.Context) error { return errors.New("failed") }, 0))
+		require.Eventually(t, func() bool {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			return p.runCtx.Err() != nil
+		}, time.Second, time.Millisecond)
+
+		// The pool is still waiting for the running task.
+		order := &startOrder{}
+		require.ErrorIs(t, p.Submit(order.task("late"), 0), ErrPoolClosed)
+		select {
+		case <-p.done:
+			require.Fail(t, "pool has stopped before its running task is done")
+		default:
+		}
+
+		close(release)
+		require.ErrorIs(t, p.Shutdown(context.Background()), ErrErrorsLimitExceeded)
+		require.Empty(t, order.names)
+	})
+
+	t.Run("extreme priorities with aging", func(t *testing.T) {
+		opts := PoolOptions{Aging: time.Hour}
+		opts.Clock = &fakeClock{}
+		p, release := blockedPool(t, opts)
+		order := &startOrder{}
+
+		require.NoError(t, p.Submit(order.task("normal"), 0))
+		require.NoError(t, p.Submit(order.task("lowest"), math.MinInt))
+		require.NoError(t, p.Submit(order.task("highest"), math.MaxInt))
+		release()
+
+		require.NoError(t, p.Shutdown(context.Background()))
+		require.Equal(t, []string{"highest", "normal", "lowest"}, order.names)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := NewPool(PoolOptions{Options: Options{Workers: 0, MaxErrors: 1}})
+		require.Error(t, err)
+	})
+}
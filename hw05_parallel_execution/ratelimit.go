@@ -0,0 +1,53 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit limits how often tasks start. The zero value means no limit.
+type RateLimit struct {
+	// Rate is the number of task starts per second.
+	Rate float64
+	// Burst is the number of tasks that can start at once, 1 if not set.
+	Burst int
+}
+
+// tokenBucket is a token bucket refilled at a constant rate.
+// Instead of counting tokens it keeps the time when the bucket is full again.
+type tokenBucket struct {
+	clock    Clock
+	interval time.Duration
+	burst    time.Duration
+	full     time.Time
+}
+
+func newTokenBucket(limit RateLimit, clock Clock) *tokenBucket {
+	interval := time.Duration(float64(time.Second) / limit.Rate)
+	return &tokenBucket{
+		clock:    clock,
+		interval: interval,
+		burst:    time.Duration(max(limit.Burst, 1)) * interval,
+		full:     clock.Now(),
+	}
+}
+
+// wait takes a token, waiting for it when the bucket is empty.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	now := b.clock.Now()
+	if b.full.Before(now) {
+		b.full = now
+	}
+	b.full = b.full.Add(b.interval)
+
+	delay := b.full.Sub(now) - b.burst
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.clock.After(delay):
+		return nil
+	}
+}
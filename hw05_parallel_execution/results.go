@@ -0,0 +1,39 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrTaskNotStarted = errors.New("task was not started")
+
+// ResultTask is a task producing a value.
+type ResultTask[T any] func(ctx context.Context) (T, error)
+
+type Result[T any] struct {
+	Value    T
+	Err      error
+	Attempts int
+}
+
+// RunResults works like RunContext and also returns the result of every task in input order.
+// Tasks skipped because the run was stopped have ErrTaskNotStarted as their error.
+func RunResults[T any](ctx context.Context, tasks []ResultTask[T], opts Options) ([]Result[T], error) {
+	results := make([]Result[T], len(tasks))
+	ctxTasks := make([]ContextTask, len(tasks))
+	for i, task := range tasks {
+		results[i].Err = ErrTaskNotStarted
+		ctxTasks[i] = func(ctx context.Context) (err error) {
+			// The panic is caught here too, so the result of a crashed task gets its error.
+			defer func() { results[i].Err = err }()
+			defer catchPanic(&err)
+
+			results[i].Attempts++
+			results[i].Value, err = task(ctx)
+			return err
+		}
+	}
+
+	err := RunContext(ctx, ctxTasks, opts)
+	return results, err
+}
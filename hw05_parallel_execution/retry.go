@@ -0,0 +1,85 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Clock tells the time and waits for it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// RetryPolicy describes how a failed task is retried. The zero value means no retries.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay when positive.
+	MaxDelay time.Duration
+	// Multiplier is how much the delay grows after every retry, 2 if not set.
+	Multiplier float64
+	// Jitter is the fraction of the delay, from 0 to 1, that is randomly cut off.
+	Jitter float64
+	// Retryable reports whether a task error is worth retrying. All errors are if it is nil.
+	Retryable func(err error) bool
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// delay returns the backoff before the given retry, starting from 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	delay := float64(p.BaseDelay)
+	for i := 1; i < retry; i++ {
+		delay *= multiplier
+		if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 {
+		delay = min(delay, float64(p.MaxDelay))
+	}
+	if p.Jitter > 0 {
+		delay -= delay * min(p.Jitter, 1) * rand.Float64() //nolint:gosec
+	}
+	return time.Duration(delay)
+}
+
+// retry runs attempt until it succeeds, fails with an error that is not retryable,
+// runs out of attempts or ctx is done. It returns the last error and the number of attempts.
+func (p RetryPolicy) retry(ctx context.Context, clock Clock, attempt func() error) (int, error) {
+	attempts := 1
+	err := attempt()
+	for ; err != nil && attempts < p.MaxAttempts && p.retryable(err); attempts++ {
+		if ctx.Err() != nil {
+			return attempts, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempts, err
+		case <-clock.After(p.delay(attempts)):
+		}
+		err = attempt()
+	}
+	return attempts, err
+}
@@ -1,17 +1,65 @@
 package hw05parallelexecution
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"runtime/debug"
+	"sort"
 	"sync"
-	"sync/atomic"
+	"time"
 )
 
-var ErrErrorsLimitExceeded = errors.New("errors limit exceeded")
+var (
+	ErrErrorsLimitExceeded = errors.New("errors limit exceeded")
+	ErrErrorRateExceeded   = errors.New("error rate exceeded")
+)
 
 type Task func() error
 
+// ContextTask is a task that should stop its work once ctx is done.
+type ContextTask func(ctx context.Context) error
+
+type Options struct {
+	// Workers is the number of goroutines running tasks.
+	Workers int
+	// MaxErrors is the number of task errors that stops the run.
+	// It may be zero when ErrorRate is set or errors are ignored.
+	MaxErrors int
+	// ErrorRate stops the run once the share of failed tasks among completed ones exceeds it.
+	ErrorRate float64
+	// MinSamples is the number of completed tasks needed before ErrorRate is checked.
+	MinSamples int
+	// IgnoreErrors runs all tasks regardless of their errors, then the run returns nil unless ctx is done.
+	IgnoreErrors bool
+	// TaskTimeout limits every attempt of a task when positive.
+	TaskTimeout time.Duration
+	// Retry is applied to failed tasks. Only the error of the last attempt counts towards MaxErrors.
+	Retry RetryPolicy
+	// RateLimit limits how often tasks start.
+	RateLimit RateLimit
+	// Clock is used to wait between retries and task starts and to time tasks, the wall clock by default.
+	Clock Clock
+	// Hooks are called as tasks run.
+	Hooks Hooks
+}
+
+func (o Options) validate() error {
+	if o.Workers <= 0 {
+		return fmt.Errorf("number of workers must be positive, got %d", o.Workers)
+	}
+	if o.ErrorRate < 0 || o.ErrorRate >= 1 {
+		return fmt.Errorf("error rate must be in [0, 1), got %v", o.ErrorRate)
+	}
+	if o.MaxErrors < 0 || o.MaxErrors == 0 && o.ErrorRate == 0 && !o.IgnoreErrors {
+		return fmt.Errorf("errors limit must be positive, got %d", o.MaxErrors)
+	}
+	return nil
+}
+
 // Run starts tasks in n goroutines and stops its work when receiving m errors from tasks.
+// If m <= 0, Run returns nil without running any task,
+// use RunContext with Options.IgnoreErrors to run all tasks regardless of their errors.
 func Run(tasks []Task, n, m int) error {
 	if n <= 0 {
 		return fmt.Errorf("number of workers must be positive, got %d", n)
@@ -20,17 +68,94 @@ func Run(tasks []Task, n, m int) error {
 		return nil
 	}
 
-	tasksChan := make(chan Task)
-	var errorCount int32
+	ctxTasks := make([]ContextTask, len(tasks))
+	for i, task := range tasks {
+		ctxTasks[i] = func(context.Context) error {
+			return task()
+		}
+	}
+	return RunContext(context.Background(), ctxTasks, Options{Workers: n, MaxErrors: m})
+}
+
+// RunContext starts tasks in opts.Workers goroutines and stops its work
+// when receiving opts.MaxErrors errors from tasks, when the error rate exceeds opts.ErrorRate
+// or when ctx is done.
+// Tasks receive a context that is cancelled in all these cases, so running tasks can stop early.
+// Tasks that have not been started by then are skipped.
+// A stopped run returns *RunError with all task errors.
+func RunContext(ctx context.Context, tasks []ContextTask, opts Options) error {
+	i := 0
+	return run(ctx, sequential(func(context.Context) (ContextTask, bool) {
+		if i == len(tasks) {
+			return nil, false
+		}
+		i++
+		return tasks[i-1], true
+	}), opts)
+}
+
+// source returns the next task or false when there are no more tasks or ctx is done.
+type source func(ctx context.Context) (indexedTask, bool)
+
+// sequential makes a source that numbers tasks in the order they are taken.
+func sequential(next func(ctx context.Context) (ContextTask, bool)) source {
+	i := 0
+	return func(ctx context.Context) (indexedTask, bool) {
+		task, ok := next(ctx)
+		if !ok {
+			return indexedTask{}, false
+		}
+		i++
+		return indexedTask{index: i - 1, task: task}, true
+	}
+}
+
+// run pulls a task from next only when a worker is free to start it,
+// so no more than opts.Workers tasks are taken from the source at a time.
+func run(ctx context.Context, next source, opts Options) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r := &runner{
+		ctx:    runCtx,
+		cancel: cancel,
+		opts:   opts,
+	}
+
+	var limiter *tokenBucket
+	if opts.RateLimit.Rate > 0 {
+		limiter = newTokenBucket(opts.RateLimit, opts.Clock)
+	}
+
+	tasksChan := make(chan indexedTask)
+	ready := make(chan struct{}, opts.Workers)
 	var wg sync.WaitGroup
 
-	wg.Add(n)
-	for i := 0; i < n; i++ {
-		go worker(tasksChan, &wg, &errorCount)
+	wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go r.worker(tasksChan, ready, &wg)
 	}
 
-	for _, task := range tasks {
-		if int(atomic.LoadInt32(&errorCount)) >= m {
+	for {
+		select {
+		case <-runCtx.Done():
+		case <-ready:
+		}
+		if runCtx.Err() != nil {
+			break
+		}
+		task, ok := next(runCtx)
+		if !ok {
+			break
+		}
+		if limiter != nil && limiter.wait(runCtx) != nil {
 			break
 		}
 		tasksChan <- task
@@ -38,19 +163,106 @@ func Run(tasks []Task, n, m int) error {
 	close(tasksChan)
 
 	wg.Wait()
-	if int(atomic.LoadInt32(&errorCount)) >= m {
-		return ErrErrorsLimitExceeded
+	cause := r.cause
+	if cause == nil {
+		cause = ctx.Err()
+	}
+	if cause == nil {
+		return nil
 	}
+	r.opts.Hooks.abort(cause)
+	return r.error(cause)
+}
 
-	return nil
+type indexedTask struct {
+	index int
+	task  ContextTask
+}
+
+type runner struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   Options
+
+	mu        sync.Mutex
+	completed int
+	errors    []*TaskError
+	cause     error
 }
 
-func worker(tasksChan <-chan Task, wg *sync.WaitGroup, errorCount *int32) {
+// worker reports to ready every time it waits for the next task.
+func (r *runner) worker(tasksChan <-chan indexedTask, ready chan<- struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	for task := range tasksChan {
-		if err := task(); err != nil {
-			atomic.AddInt32(errorCount, 1)
+	for {
+		ready <- struct{}{}
+		t, ok := <-tasksChan
+		if !ok {
+			return
 		}
+		if r.ctx.Err() != nil {
+			continue
+		}
+		r.opts.Hooks.start(t.index)
+		started := r.opts.Clock.Now()
+		attempts, err := r.opts.Retry.retry(r.ctx, r.opts.Clock, func() error {
+			return r.runTask(t.task)
+		})
+		r.opts.Hooks.finish(t.index, r.opts.Clock.Now().Sub(started), err)
+		r.complete(t.index, attempts, err)
 	}
 }
+
+// complete records the result of a task and stops the run when the error policy says so.
+func (r *runner) complete(index, attempts int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.completed++
+	if err == nil {
+		return
+	}
+	r.errors = append(r.errors, &TaskError{Index: index, Err: err, Attempts: attempts})
+	if r.cause != nil || r.opts.IgnoreErrors {
+		return
+	}
+
+	failed := len(r.errors)
+	switch {
+	case r.opts.MaxErrors > 0 && failed >= r.opts.MaxErrors:
+		r.cause = ErrErrorsLimitExceeded
+	case r.opts.ErrorRate > 0 && r.completed >= r.opts.MinSamples &&
+		float64(failed) > r.opts.ErrorRate*float64(r.completed):
+		r.cause = ErrErrorRateExceeded
+	default:
+		return
+	}
+	r.cancel()
+}
+
+// runTask runs a single attempt of a task, turning its panic into *PanicError.
+func (r *runner) runTask(task ContextTask) (err error) {
+	defer catchPanic(&err)
+
+	ctx := r.ctx
+	if r.opts.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.TaskTimeout)
+		defer cancel()
+	}
+	return task(ctx)
+}
+
+// catchPanic turns a panic of the function deferring it into *PanicError stored in err.
+func catchPanic(err *error) {
+	if v := recover(); v != nil {
+		*err = &PanicError{Value: v, Stack: debug.Stack()}
+	}
+}
+
+func (r *runner) error(cause error) error {
+	sort.Slice(r.errors, func(i, j int) bool {
+		return r.errors[i].Index < r.errors[j].Index
+	})
+	return &RunError{Cause: cause, Errors: r.errors}
+}
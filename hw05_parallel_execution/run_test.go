@@ -1,6 +1,7 @@
 package hw05parallelexecution
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -124,3 +125,939 @@ func TestRun(t *testing.T) {
 		require.LessOrEqual(t, int64(elapsedTime), int64(sumTime/2), "tasks were run sequentially?")
 	})
 }
+
+func TestRunContext(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("errors limit cancels running tasks", func(t *testing.T) {
+		tasksCount := 50
+		tasks := make([]ContextTask, 0, tasksCount)
+
+		var startedTasksCount int32
+		for i := 0; i < tasksCount; i++ {
+			fail := i < 2
+			tasks = append(tasks, func(ctx context.Context) error {
+				atomic.AddInt32(&startedTasksCount, 1)
+				if fail {
+					return fmt.Errorf("error from task %d", i)
+				}
+				<-ctx.Done()
+				return nil
+			})
+		}
+
+		workersCount := 5
+		err := RunContext(context.Background(), tasks, Options{Workers: workersCount, MaxErrors: 2})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.LessOrEqual(t, startedTasksCount, int32(workersCount+2), "extra tasks were started")
+	})
+
+	t.Run("parent context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var startedTasksCount int32
+		tasks := make([]ContextTask, 20)
+		for i := range tasks {
+			tasks[i] = func(ctx context.Context) error {
+				if atomic.AddInt32(&startedTasksCount, 1) == 3 {
+					cancel()
+				}
+				<-ctx.Done()
+				return ctx.Err()
+			}
+		}
+
+		err := RunContext(ctx, tasks, Options{Workers: 3, MaxErrors: 100})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.LessOrEqual(t, startedTasksCount, int32(6))
+	})
+
+	t.Run("task timeout", func(t *testing.T) {
+		var timedOut int32
+		tasks := []ContextTask{
+			func(ctx context.Context) error {
+				<-ctx.Done()
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					atomic.AddInt32(&timedOut, 1)
+				}
+				return ctx.Err()
+			},
+			func(context.Context) error {
+				return nil
+			},
+		}
+
+		opts := Options{Workers: 2, MaxErrors: 2, TaskTimeout: 10 * time.Millisecond}
+		err := RunContext(context.Background(), tasks, opts)
+
+		require.NoError(t, err)
+		require.Equal(t, int32(1), timedOut)
+
+		opts.MaxErrors = 1
+		err = RunContext(context.Background(), tasks, opts)
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+	})
+
+	t.Run("all tasks are completed", func(t *testing.T) {
+		var runTasksCount int32
+		tasks := make([]ContextTask, 30)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error {
+				atomic.AddInt32(&runTasksCount, 1)
+				return nil
+			}
+		}
+
+		err := RunContext(context.Background(), tasks, Options{Workers: 4, MaxErrors: 1})
+
+		require.NoError(t, err)
+		require.Equal(t, int32(len(tasks)), runTasksCount)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		err := RunContext(context.Background(), nil, Options{Workers: 0, MaxErrors: 1})
+		require.Error(t, err)
+
+		err = RunContext(context.Background(), nil, Options{Workers: 1, MaxErrors: 0})
+		require.Error(t, err)
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("task errors are collected", func(t *testing.T) {
+		errFirst := errors.New("first")
+		errSecond := errors.New("second")
+		tasks := []ContextTask{
+			func(context.Context) error { return nil },
+			func(context.Context) error { return errFirst },
+			func(context.Context) error { return nil },
+			func(context.Context) error { return errSecond },
+		}
+
+		err := RunContext(context.Background(), tasks, Options{Workers: 1, MaxErrors: 2})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.ErrorIs(t, err, errFirst)
+		require.ErrorIs(t, err, errSecond)
+		require.EqualError(t, err, "errors limit exceeded: task 1: first; task 3: second")
+
+		var runErr *RunError
+		require.ErrorAs(t, err, &runErr)
+		require.Equal(t, ErrErrorsLimitExceeded, runErr.Cause)
+		require.Equal(t, []*TaskError{
+			{Index: 1, Err: errFirst, Attempts: 1},
+			{Index: 3, Err: errSecond, Attempts: 1},
+		}, runErr.Errors)
+
+		var taskErr *TaskError
+		require.ErrorAs(t, err, &taskErr)
+		require.Equal(t, 1, taskErr.Index)
+	})
+
+	t.Run("errors are ordered by task index", func(t *testing.T) {
+		tasks := make([]ContextTask, 10)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error {
+				time.Sleep(time.Millisecond * time.Duration(10-i))
+				return fmt.Errorf("error from task %d", i)
+			}
+		}
+
+		err := RunContext(context.Background(), tasks, Options{Workers: 10, MaxErrors: 10})
+
+		var runErr *RunError
+		require.ErrorAs(t, err, &runErr)
+		require.Len(t, runErr.Errors, 10)
+		for i, taskErr := range runErr.Errors {
+			require.Equal(t, i, taskErr.Index)
+		}
+	})
+
+	t.Run("cancelled run keeps task errors", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		errTask := errors.New("task failed")
+		tasks := []ContextTask{
+			func(context.Context) error {
+				cancel()
+				return errTask
+			},
+		}
+
+		err := RunContext(ctx, tasks, Options{Workers: 1, MaxErrors: 10})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.ErrorIs(t, err, errTask)
+		require.NotErrorIs(t, err, ErrErrorsLimitExceeded)
+	})
+
+	t.Run("errors below the limit", func(t *testing.T) {
+		tasks := []ContextTask{
+			func(context.Context) error { return errors.New("failed") },
+			func(context.Context) error { return nil },
+		}
+
+		err := RunContext(context.Background(), tasks, Options{Workers: 2, MaxErrors: 2})
+		require.NoError(t, err)
+	})
+}
+
+func TestRunResults(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("results in input order", func(t *testing.T) {
+		errOdd := errors.New("odd")
+		tasks := make([]ResultTask[int], 20)
+		for i := range tasks {
+			tasks[i] = func(context.Context) (int, error) {
+				time.Sleep(time.Millisecond * time.Duration(rand.Intn(5)))
+				if i%2 == 1 {
+					return 0, errOdd
+				}
+				return i * i, nil
+			}
+		}
+
+		results, err := RunResults(context.Background(), tasks, Options{Workers: 4, MaxErrors: 100})
+
+		require.NoError(t, err)
+		require.Len(t, results, len(tasks))
+		for i, result := range results {
+			if i%2 == 1 {
+				require.ErrorIs(t, result.Err, errOdd)
+				continue
+			}
+			require.NoError(t, result.Err)
+			require.Equal(t, i*i, result.Value)
+		}
+	})
+
+	t.Run("skipped tasks", func(t *testing.T) {
+		tasks := []ResultTask[string]{
+			func(context.Context) (string, error) { return "", errors.New("failed") },
+			func(context.Context) (string, error) { return "not started", nil },
+		}
+
+		results, err := RunResults(context.Background(), tasks, Options{Workers: 1, MaxErrors: 1})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.EqualError(t, results[0].Err, "failed")
+		require.ErrorIs(t, results[1].Err, ErrTaskNotStarted)
+		require.Empty(t, results[1].Value)
+	})
+}
+
+// fakeClock fires every timer at once and records the requested delays.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	delays []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.delays = append(c.delays, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *fakeClock) Delays() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.delays...)
+}
+
+func TestRunRetry(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errFlaky := errors.New("flaky")
+
+	t.Run("flaky task succeeds without counting errors", func(t *testing.T) {
+		clock := &fakeClock{}
+		var calls int32
+		tasks := []ContextTask{
+			func(context.Context) error {
+				if atomic.AddInt32(&calls, 1) < 3 {
+					return errFlaky
+				}
+				return nil
+			},
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: 1,
+			Retry:     RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond},
+			Clock:     clock,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, int32(3), calls)
+		require.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, clock.Delays())
+	})
+
+	t.Run("errors that are not retryable fail at once", func(t *testing.T) {
+		clock := &fakeClock{}
+		errFatal := errors.New("fatal")
+		var calls int32
+		tasks := []ContextTask{
+			func(context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				return errFatal
+			},
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: 1,
+			Retry: RetryPolicy{
+				MaxAttempts: 5,
+				BaseDelay:   time.Second,
+				Retryable:   func(err error) bool { return errors.Is(err, errFlaky) },
+			},
+			Clock: clock,
+		})
+
+		require.ErrorIs(t, err, errFatal)
+		require.Equal(t, int32(1), calls)
+		require.Empty(t, clock.Delays())
+	})
+
+	t.Run("only final failures count towards the limit", func(t *testing.T) {
+		clock := &fakeClock{}
+		tasks := make([]ContextTask, 10)
+		for i := range tasks {
+			var calls int32
+			tasks[i] = func(context.Context) error {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					return errFlaky
+				}
+				return nil
+			}
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   3,
+			MaxErrors: 1,
+			Retry:     RetryPolicy{MaxAttempts: 2},
+			Clock:     clock,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, clock.Delays(), 10)
+	})
+
+	t.Run("cancelled run stops retrying", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var calls int32
+		tasks := []ContextTask{
+			func(context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				cancel()
+				return errFlaky
+			},
+		}
+
+		err := RunContext(ctx, tasks, Options{
+			Workers:   1,
+			MaxErrors: 10,
+			Retry:     RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour},
+		})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, int32(1), calls)
+	})
+
+	t.Run("results report attempts", func(t *testing.T) {
+		var calls int32
+		tasks := []ResultTask[string]{
+			func(context.Context) (string, error) { return "ok", nil },
+			func(context.Context) (string, error) {
+				if atomic.AddInt32(&calls, 1) < 2 {
+					return "", errFlaky
+				}
+				return "retried", nil
+			},
+		}
+
+		results, err := RunResults(context.Background(), tasks, Options{
+			Workers:   2,
+			MaxErrors: 1,
+			Retry:     RetryPolicy{MaxAttempts: 3},
+			Clock:     &fakeClock{},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, []Result[string]{
+			{Value: "ok", Attempts: 1},
+			{Value: "retried", Attempts: 2},
+		}, results)
+	})
+}
+
+func TestRunRetryBackoff(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	errFlaky := errors.New("flaky")
+
+	t.Run("exponential backoff is capped", func(t *testing.T) {
+		clock := &fakeClock{}
+		tasks := []ContextTask{
+			func(context.Context) error { return errFlaky },
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: 1,
+			Retry: RetryPolicy{
+				MaxAttempts: 5,
+				BaseDelay:   time.Second,
+				MaxDelay:    5 * time.Second,
+				Multiplier:  3,
+			},
+			Clock: clock,
+		})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.EqualError(t, err, "errors limit exceeded: task 0 (5 attempts): flaky")
+		require.Equal(t, []time.Duration{
+			time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second,
+		}, clock.Delays())
+
+		var taskErr *TaskError
+		require.ErrorAs(t, err, &taskErr)
+		require.Equal(t, 5, taskErr.Attempts)
+	})
+
+	t.Run("jitter shortens delays", func(t *testing.T) {
+		clock := &fakeClock{}
+		tasks := []ContextTask{
+			func(context.Context) error { return errFlaky },
+		}
+
+		_ = RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: 1,
+			Retry:     RetryPolicy{MaxAttempts: 20, BaseDelay: time.Second, MaxDelay: time.Second, Jitter: 0.5},
+			Clock:     clock,
+		})
+
+		delays := clock.Delays()
+		require.Len(t, delays, 19)
+		for _, d := range delays {
+			require.GreaterOrEqual(t, d, 500*time.Millisecond)
+			require.LessOrEqual(t, d, time.Second)
+		}
+	})
+}
+
+func TestRunSeq(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("all tasks from sequence are run", func(t *testing.T) {
+		var runTasksCount int32
+		tasks := func(yield func(Task) bool) {
+			for i := 0; i < 50; i++ {
+				if !yield(func() error {
+					atomic.AddInt32(&runTasksCount, 1)
+					return nil
+				}) {
+					return
+				}
+			}
+		}
+
+		err := RunSeq(tasks, 5, 1)
+
+		require.NoError(t, err)
+		require.Equal(t, int32(50), runTasksCount)
+	})
+
+	t.Run("endless sequence stops on errors limit", func(t *testing.T) {
+		workersCount := 4
+		maxErrorsCount := 10
+		var pulled, finished, maxAhead int32
+		tasks := func(yield func(Task) bool) {
+			for {
+				ahead := atomic.AddInt32(&pulled, 1) - atomic.LoadInt32(&finished)
+				if ahead > atomic.LoadInt32(&maxAhead) {
+					atomic.StoreInt32(&maxAhead, ahead)
+				}
+				if !yield(func() error {
+					defer atomic.AddInt32(&finished, 1)
+					return errors.New("failed")
+				}) {
+					return
+				}
+			}
+		}
+
+		err := RunSeq(tasks, workersCount, maxErrorsCount)
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.LessOrEqual(t, pulled, int32(workersCount+maxErrorsCount), "pulled too many tasks")
+		require.LessOrEqual(t, maxAhead, int32(workersCount), "buffered more tasks than workers")
+	})
+
+	t.Run("task indexes are sequence positions", func(t *testing.T) {
+		errTask := errors.New("failed")
+		tasks := func(yield func(ContextTask) bool) {
+			for i := 0; i < 5; i++ {
+				if !yield(func(context.Context) error {
+					if i == 3 {
+						return errTask
+					}
+					return nil
+				}) {
+					return
+				}
+			}
+		}
+
+		err := RunSeqContext(context.Background(), tasks, Options{Workers: 2, MaxErrors: 1})
+
+		var taskErr *TaskError
+		require.ErrorAs(t, err, &taskErr)
+		require.Equal(t, 3, taskErr.Index)
+	})
+}
+
+func TestRunChanContext(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("tasks are received until channel is closed", func(t *testing.T) {
+		var runTasksCount int32
+		tasks := make(chan ContextTask)
+		go func() {
+			defer close(tasks)
+			for i := 0; i < 30; i++ {
+				tasks <- func(context.Context) error {
+					atomic.AddInt32(&runTasksCount, 1)
+					return nil
+				}
+			}
+		}()
+
+		err := RunChanContext(context.Background(), tasks, Options{Workers: 3, MaxErrors: 1})
+
+		require.NoError(t, err)
+		require.Equal(t, int32(30), runTasksCount)
+	})
+
+	t.Run("errors limit stops waiting on open channel", func(t *testing.T) {
+		tasks := make(chan ContextTask, 3)
+		for i := 0; i < 3; i++ {
+			tasks <- func(context.Context) error { return errors.New("failed") }
+		}
+
+		err := RunChanContext(context.Background(), tasks, Options{Workers: 2, MaxErrors: 3})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+	})
+
+	t.Run("cancelled context stops waiting on open channel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		err := RunChanContext(ctx, make(chan ContextTask), Options{Workers: 2, MaxErrors: 1})
+
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRunRateLimit(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("task starts follow rate and burst", func(t *testing.T) {
+		clock := &fakeClock{}
+		var mu sync.Mutex
+		var starts []time.Duration
+		tasks := make([]ContextTask, 6)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				starts = append(starts, clock.Now().Sub(time.Time{}))
+				return nil
+			}
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: 1,
+			RateLimit: RateLimit{Rate: 10, Burst: 2},
+			Clock:     clock,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, []time.Duration{
+			100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond,
+		}, clock.Delays())
+		require.Equal(t, []time.Duration{
+			0, 0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 400 * time.Millisecond,
+		}, starts)
+	})
+
+	t.Run("bucket refills while tasks run", func(t *testing.T) {
+		clock := &fakeClock{}
+		tasks := make([]ContextTask, 4)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error {
+				clock.After(time.Second)
+				return nil
+			}
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: 1,
+			RateLimit: RateLimit{Rate: 2},
+			Clock:     clock,
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, []time.Duration{time.Second, time.Second, time.Second, time.Second}, clock.Delays())
+	})
+
+	t.Run("cancelled context stops waiting for tokens", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		var runTasksCount int32
+		tasks := make([]ContextTask, 3)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error {
+				atomic.AddInt32(&runTasksCount, 1)
+				return nil
+			}
+		}
+
+		err := RunContext(ctx, tasks, Options{Workers: 3, MaxErrors: 1, RateLimit: RateLimit{Rate: 0.001}})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, int32(1), runTasksCount)
+	})
+}
+
+func TestRunWeighted(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	const budget = 5
+	sem := NewSemaphore(budget)
+	var used, maxUsed int32
+	tasks := make([]ContextTask, 30)
+	for i := range tasks {
+		weight := int64(i%budget + 1)
+		tasks[i] = Weighted(sem, weight, func(context.Context) error {
+			current := atomic.AddInt32(&used, int32(weight))
+			defer atomic.AddInt32(&used, -int32(weight))
+			for {
+				prev := atomic.LoadInt32(&maxUsed)
+				if current <= prev || atomic.CompareAndSwapInt32(&maxUsed, prev, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}
+
+	err := RunContext(context.Background(), tasks, Options{Workers: 10, MaxErrors: 1})
+
+	require.NoError(t, err)
+	require.LessOrEqual(t, maxUsed, int32(budget), "budget was exceeded")
+	require.True(t, sem.TryAcquire(budget), "weight was not released")
+}
+
+func TestRunErrorPolicies(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	failing := func(count int, failed func(i int) bool) ([]ContextTask, *int32) {
+		var runTasksCount int32
+		tasks := make([]ContextTask, count)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error {
+				atomic.AddInt32(&runTasksCount, 1)
+				if failed(i) {
+					return fmt.Errorf("error from task %d", i)
+				}
+				return nil
+			}
+		}
+		return tasks, &runTasksCount
+	}
+
+	t.Run("non-positive m runs nothing", func(t *testing.T) {
+		var runTasksCount int32
+		tasks := []Task{
+			func() error {
+				atomic.AddInt32(&runTasksCount, 1)
+				return errors.New("failed")
+			},
+		}
+
+		require.NoError(t, Run(tasks, 1, 0))
+		require.NoError(t, Run(tasks, 1, -1))
+		require.Equal(t, int32(0), runTasksCount)
+	})
+
+	t.Run("ignore errors runs everything", func(t *testing.T) {
+		tasks, runTasksCount := failing(50, func(int) bool { return true })
+
+		err := RunContext(context.Background(), tasks, Options{Workers: 5, IgnoreErrors: true})
+
+		require.NoError(t, err)
+		require.Equal(t, int32(50), *runTasksCount)
+	})
+
+	t.Run("ignore errors still stops on cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tasks, runTasksCount := failing(10, func(int) bool { return true })
+
+		err := RunContext(ctx, tasks, Options{Workers: 2, IgnoreErrors: true})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, int32(0), *runTasksCount)
+	})
+
+	t.Run("error rate stops the run", func(t *testing.T) {
+		tasks, runTasksCount := failing(100, func(i int) bool { return i >= 20 })
+
+		err := RunContext(context.Background(), tasks, Options{Workers: 1, ErrorRate: 0.1, MinSamples: 10})
+
+		require.ErrorIs(t, err, ErrErrorRateExceeded)
+		require.NotErrorIs(t, err, ErrErrorsLimitExceeded)
+		// 20 successes and then 3 errors make the rate 3/23 > 10%.
+		require.Equal(t, int32(23), *runTasksCount)
+	})
+
+	t.Run("error rate waits for minimum samples", func(t *testing.T) {
+		tasks, runTasksCount := failing(100, func(i int) bool { return i < 5 })
+
+		err := RunContext(context.Background(), tasks, Options{Workers: 1, ErrorRate: 0.5, MinSamples: 10})
+
+		require.NoError(t, err)
+		require.Equal(t, int32(100), *runTasksCount)
+	})
+
+	t.Run("error rate below threshold", func(t *testing.T) {
+		tasks, runTasksCount := failing(100, func(i int) bool { return i%10 == 9 })
+
+		err := RunContext(context.Background(), tasks, Options{Workers: 4, ErrorRate: 0.2, MinSamples: 20})
+
+		require.NoError(t, err)
+		require.Equal(t, int32(100), *runTasksCount)
+	})
+
+	t.Run("errors limit and error rate together", func(t *testing.T) {
+		tasks, _ := failing(100, func(i int) bool { return i%2 == 0 })
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:    1,
+			MaxErrors:  3,
+			ErrorRate:  0.9,
+			MinSamples: 4,
+		})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		tasks, _ := failing(1, func(int) bool { return false })
+
+		require.Error(t, RunContext(context.Background(), tasks, Options{Workers: 1}))
+		require.Error(t, RunContext(context.Background(), tasks, Options{Workers: 1, MaxErrors: -1, IgnoreErrors: true}))
+		require.Error(t, RunContext(context.Background(), tasks, Options{Workers: 1, ErrorRate: 1}))
+		require.Error(t, RunContext(context.Background(), tasks, Options{Workers: 1, ErrorRate: -0.1}))
+	})
+}
+
+func TestRunPanics(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("panic is returned as error with stack", func(t *testing.T) {
+		tasks := []Task{
+			func() error { return nil },
+			func() error { panic("boom") },
+		}
+
+		err := Run(tasks, 2, 1)
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		var panicErr *PanicError
+		require.ErrorAs(t, err, &panicErr)
+		require.Equal(t, "boom", panicErr.Value)
+		require.Contains(t, string(panicErr.Stack), "run_test.go")
+		require.EqualError(t, err, "errors limit exceeded: task 1: panic: boom")
+	})
+
+	t.Run("panics count towards the limit", func(t *testing.T) {
+		errPanic := errors.New("panic value")
+		var runTasksCount int32
+		tasks := make([]Task, 20)
+		for i := range tasks {
+			tasks[i] = func() error {
+				atomic.AddInt32(&runTasksCount, 1)
+				if i%2 == 0 {
+					panic(errPanic)
+				}
+				return nil
+			}
+		}
+
+		err := Run(tasks, 1, 3)
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.ErrorIs(t, err, errPanic)
+		require.Equal(t, int32(5), runTasksCount)
+	})
+
+	t.Run("panicking attempt is retried", func(t *testing.T) {
+		var calls int32
+		tasks := []ContextTask{
+			func(context.Context) error {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					panic("first attempt")
+				}
+				return nil
+			},
+		}
+
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: 1,
+			Retry:     RetryPolicy{MaxAttempts: 2},
+			Clock:     &fakeClock{},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, int32(2), calls)
+	})
+
+	t.Run("panicking result task has panic error", func(t *testing.T) {
+		tasks := []ResultTask[int]{
+			func(context.Context) (int, error) { return 1, nil },
+			func(context.Context) (int, error) { panic("boom") },
+		}
+
+		results, err := RunResults(context.Background(), tasks, Options{Workers: 1, MaxErrors: 1})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.Equal(t, Result[int]{Value: 1, Attempts: 1}, results[0])
+		var panicErr *PanicError
+		require.ErrorAs(t, results[1].Err, &panicErr)
+		require.Equal(t, "boom", panicErr.Value)
+		require.Equal(t, 1, results[1].Attempts)
+	})
+}
+
+func TestRunHooks(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	type finish struct {
+		index    int
+		duration time.Duration
+		err      error
+	}
+
+	t.Run("hooks observe every task", func(t *testing.T) {
+		clock := &fakeClock{}
+		errTask := errors.New("failed")
+		tasks := make([]ContextTask, 4)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error {
+				clock.After(time.Duration(i+1) * time.Second)
+				if i == 2 {
+					return errTask
+				}
+				return nil
+			}
+		}
+
+		var starts []int
+		var finishes []finish
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   1,
+			MaxErrors: 10,
+			Clock:     clock,
+			Hooks: Hooks{
+				OnStart: func(index int) { starts = append(starts, index) },
+				OnFinish: func(index int, duration time.Duration, err error) {
+					finishes = append(finishes, finish{index: index, duration: duration, err: err})
+				},
+				OnAbort: func(error) { require.Fail(t, "run is not aborted") },
+			},
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, []int{0, 1, 2, 3}, starts)
+		require.Equal(t, []finish{
+			{index: 0, duration: time.Second},
+			{index: 1, duration: 2 * time.Second},
+			{index: 2, duration: 3 * time.Second, err: errTask},
+			{index: 3, duration: 4 * time.Second},
+		}, finishes)
+	})
+
+	t.Run("abort is reported once with its cause", func(t *testing.T) {
+		tasks := make([]ContextTask, 10)
+		for i := range tasks {
+			tasks[i] = func(context.Context) error { return errors.New("failed") }
+		}
+
+		var mu sync.Mutex
+		var causes []error
+		var started, finished int32
+		err := RunContext(context.Background(), tasks, Options{
+			Workers:   3,
+			MaxErrors: 2,
+			Hooks: Hooks{
+				OnStart:  func(int) { atomic.AddInt32(&started, 1) },
+				OnFinish: func(int, time.Duration, error) { atomic.AddInt32(&finished, 1) },
+				OnAbort: func(cause error) {
+					mu.Lock()
+					defer mu.Unlock()
+					causes = append(causes, cause)
+				},
+			},
+		})
+
+		require.ErrorIs(t, err, ErrErrorsLimitExceeded)
+		require.Equal(t, []error{ErrErrorsLimitExceeded}, causes)
+		require.Equal(t, started, finished)
+	})
+
+	t.Run("abort on cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var cause error
+		err := RunContext(ctx, []ContextTask{func(context.Context) error { return nil }}, Options{
+			Workers:   1,
+			MaxErrors: 1,
+			Hooks:     Hooks{OnAbort: func(err error) { cause = err }},
+		})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.ErrorIs(t, cause, context.Canceled)
+	})
+}
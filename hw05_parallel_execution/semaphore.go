@@ -0,0 +1,116 @@
+package hw05parallelexecution
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrWeightTooLarge = errors.New("weight exceeds semaphore size")
+
+// Semaphore limits the total weight of tasks running at once.
+// Waiters are served in FIFO order, so heavy tasks are not starved by light ones.
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int64
+	used    int64
+	waiters list.List
+}
+
+type waiter struct {
+	weight int64
+	ready  chan struct{}
+}
+
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+// Acquire waits until weight is available or ctx is done.
+func (s *Semaphore) Acquire(ctx context.Context, weight int64) error {
+	s.mu.Lock()
+	if weight > s.size {
+		s.mu.Unlock()
+		return ErrWeightTooLarge
+	}
+	if s.waiters.Len() == 0 && s.used+weight <= s.size {
+		s.used += weight
+		s.mu.Unlock()
+		return nil
+	}
+
+	w := waiter{weight: weight, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		select {
+		case <-w.ready:
+			// Acquired while being cancelled.
+			return nil
+		default:
+		}
+		front := s.waiters.Front() == elem
+		s.waiters.Remove(elem)
+		if front {
+			s.notify()
+		}
+		return ctx.Err()
+	}
+}
+
+// TryAcquire acquires weight only if it is available at once and nobody is waiting.
+func (s *Semaphore) TryAcquire(weight int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.waiters.Len() > 0 || s.used+weight > s.size {
+		return false
+	}
+	s.used += weight
+	return true
+}
+
+// Release returns weight to the semaphore.
+func (s *Semaphore) Release(weight int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.used -= weight
+	if s.used < 0 {
+		panic("semaphore: released more than held")
+	}
+	s.notify()
+}
+
+// notify wakes waiters from the front of the queue while their weight fits.
+func (s *Semaphore) notify() {
+	for elem := s.waiters.Front(); elem != nil; elem = s.waiters.Front() {
+		w := elem.Value.(waiter)
+		if s.used+w.weight > s.size {
+			return
+		}
+		s.used += w.weight
+		s.waiters.Remove(elem)
+		close(w.ready)
+	}
+}
+
+// Weighted returns a task that holds weight of sem while it runs.
+func Weighted(sem *Semaphore, weight int64, task ContextTask) ContextTask {
+	return func(ctx context.Context) error {
+		if err := sem.Acquire(ctx, weight); err != nil {
+			return err
+		}
+		defer sem.Release(weight)
+
+		return task(ctx)
+	}
+}
@@ -0,0 +1,76 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestSemaphore(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	t.Run("weight is limited by size", func(t *testing.T) {
+		sem := NewSemaphore(5)
+
+		require.NoError(t, sem.Acquire(context.Background(), 3))
+		require.True(t, sem.TryAcquire(2))
+		require.False(t, sem.TryAcquire(1))
+
+		sem.Release(3)
+		require.True(t, sem.TryAcquire(3))
+		require.False(t, sem.TryAcquire(1))
+	})
+
+	t.Run("too large weight", func(t *testing.T) {
+		sem := NewSemaphore(5)
+
+		require.ErrorIs(t, sem.Acquire(context.Background(), 6), ErrWeightTooLarge)
+		require.False(t, sem.TryAcquire(6))
+	})
+
+	t.Run("waiters are served in order", func(t *testing.T) {
+		sem := NewSemaphore(5)
+		require.True(t, sem.TryAcquire(5))
+
+		heavy := make(chan error)
+		go func() { heavy <- sem.Acquire(context.Background(), 4) }()
+		require.Eventually(t, func() bool { return !sem.TryAcquire(0) }, time.Second, time.Millisecond)
+
+		light := make(chan error)
+		go func() { light <- sem.Acquire(context.Background(), 1) }()
+
+		sem.Release(1)
+		select {
+		case <-light:
+			require.Fail(t, "light waiter overtook heavy one")
+		default:
+		}
+
+		sem.Release(3)
+		require.NoError(t, <-heavy)
+
+		sem.Release(1)
+		require.NoError(t, <-light)
+		require.False(t, sem.TryAcquire(1))
+	})
+
+	t.Run("cancelled waiter lets next one in", func(t *testing.T) {
+		sem := NewSemaphore(5)
+		require.True(t, sem.TryAcquire(3))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		heavy := make(chan error)
+		go func() { heavy <- sem.Acquire(ctx, 5) }()
+		require.Eventually(t, func() bool { return !sem.TryAcquire(0) }, time.Second, time.Millisecond)
+
+		light := make(chan error)
+		go func() { light <- sem.Acquire(context.Background(), 2) }()
+
+		cancel()
+		require.ErrorIs(t, <-heavy, context.Canceled)
+		require.NoError(t, <-light)
+	})
+}
@@ -0,0 +1,52 @@
+package hw05parallelexecution
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// RunSeq works like Run but takes tasks from an iterator as workers become free,
+// so no more than n tasks are taken from it ahead of their start.
+// It stops taking tasks once m errors are received.
+func RunSeq(tasks iter.Seq[Task], n, m int) error {
+	if n <= 0 {
+		return fmt.Errorf("number of workers must be positive, got %d", n)
+	}
+	if m <= 0 {
+		return nil
+	}
+
+	ctxTasks := func(yield func(ContextTask) bool) {
+		for task := range tasks {
+			if !yield(func(context.Context) error { return task() }) {
+				return
+			}
+		}
+	}
+	return RunSeqContext(context.Background(), ctxTasks, Options{Workers: n, MaxErrors: m})
+}
+
+// RunSeqContext works like RunContext but takes tasks from an iterator as workers become free.
+// Task indexes in errors are positions in the sequence.
+func RunSeqContext(ctx context.Context, tasks iter.Seq[ContextTask], opts Options) error {
+	next, stop := iter.Pull(tasks)
+	defer stop()
+
+	return run(ctx, sequential(func(context.Context) (ContextTask, bool) {
+		return next()
+	}), opts)
+}
+
+// RunChanContext works like RunContext but receives tasks from a channel as workers become free
+// until it is closed. Waiting for a task is interrupted when the run stops.
+func RunChanContext(ctx context.Context, tasks <-chan ContextTask, opts Options) error {
+	return run(ctx, sequential(func(ctx context.Context) (ContextTask, bool) {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case task, ok := <-tasks:
+			return task, ok
+		}
+	}), opts)
+}
@@ -0,0 +1,19 @@
+package hw06pipelineexecution
+
+import "context"
+
+// ContextStage is an untyped stage of ExecutePipelineContext.
+type ContextStage = TypedStage[interface{}, interface{}]
+
+// ExecutePipelineContext runs stages one after another, each in its own goroutine, just like Execute does.
+// A stage that returns early stops the stages before it.
+func ExecutePipelineContext(ctx context.Context, in In, stages ...ContextStage) (Out, <-chan error) {
+	stage := ContextStage(forward[interface{}])
+	if len(stages) > 0 {
+		stage = stages[0]
+		for _, next := range stages[1:] {
+			stage = Chain(stage, next)
+		}
+	}
+	return Execute(ctx, in, stage)
+}
@@ -0,0 +1,171 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checkLeaks fails t if goroutines running the code of the package are still alive
+// some time after the test, user stages defined in tests are not counted.
+func checkLeaks(t *testing.T) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		deadline := time.Now().Add(drainTimeout + time.Second)
+		leaked := pipelineGoroutines()
+		for len(leaked) > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			leaked = pipelineGoroutines()
+		}
+		if len(leaked) > 0 {
+			t.Errorf("%d goroutines leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	})
+}
+
+// pipelineGoroutines returns stacks of other goroutines that have frames in non-test files of the package.
+func pipelineGoroutines() []string {
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(file) + "/"
+
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var leaked []string
+	// The first stack is the current goroutine.
+	for _, stack := range strings.Split(string(buf), "\n\n")[1:] {
+		for _, line := range strings.Split(stack, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, dir) && !strings.Contains(line, "_test.go:") {
+				leaked = append(leaked, stack)
+				break
+			}
+		}
+	}
+	return leaked
+}
+
+func TestPipelineLeaks(t *testing.T) {
+	// Misbehaving stages are released after the leak check, so they do not affect other tests.
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	endless := func(In) Out {
+		out := make(Bi)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case out <- i:
+				case <-release:
+					return
+				}
+			}
+		}()
+		return out
+	}
+	stuck := func(In) Out {
+		out := make(Bi)
+		go func() {
+			<-release
+		}()
+		return out
+	}
+	pass := func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			for v := range in {
+				out <- v
+			}
+		}()
+		return out
+	}
+
+	for name, stages := range map[string][]Stage{
+		"endless stage":       {endless, pass},
+		"stuck stage":         {pass, stuck, pass},
+		"endless then stuck":  {endless, stuck},
+		"stuck at the end":    {pass, stuck},
+		"endless at the end":  {pass, endless},
+		"well-behaved stages": {pass, pass, pass},
+	} {
+		t.Run(name, func(t *testing.T) {
+			checkLeaks(t)
+
+			in := make(Bi)
+			go func() {
+				for i := 0; ; i++ {
+					select {
+					case in <- i:
+					case <-release:
+						return
+					}
+				}
+			}()
+			done := make(Bi)
+			time.AfterFunc(10*time.Millisecond, func() { close(done) })
+
+			start := time.Now()
+			for v := range ExecutePipeline(in, done, stages...) {
+				_ = v
+			}
+			require.Less(t, time.Since(start), time.Second)
+		})
+	}
+
+	t.Run("endless input of context pipeline", func(t *testing.T) {
+		checkLeaks(t)
+
+		in := make(chan int)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case in <- i:
+				case <-release:
+					return
+				}
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		out, errc := Execute(ctx, in, ParallelMap(func(_ context.Context, v int) (int, error) {
+			return v, nil
+		}, 3, Ordered))
+		for v := range out {
+			_ = v
+		}
+		require.ErrorIs(t, <-errc, context.DeadlineExceeded)
+	})
+
+	t.Run("blocked reader of context pipeline", func(t *testing.T) {
+		checkLeaks(t)
+
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			for i := 0; i < 100; i++ {
+				in <- i
+			}
+		}()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		out, errc := Execute(ctx, in, Configure(Batch[int](3, time.Second), WithBuffer(10)))
+		<-out
+		cancel()
+		require.ErrorIs(t, <-errc, context.Canceled)
+	})
+}
@@ -0,0 +1,170 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StageStats is a snapshot of Metrics.
+type StageStats struct {
+	// ItemsIn is the number of values the stage has taken from its input.
+	ItemsIn int64
+	// ItemsOut is the number of results the stage has sent.
+	ItemsOut int64
+	// RecvBlocked is the time spent waiting for values from the previous stage.
+	RecvBlocked time.Duration
+	// SendBlocked is the time spent waiting for the next stage to take results.
+	SendBlocked time.Duration
+	// QueueDepth is the number of values waiting in the input queues of the running stages sharing the metrics,
+	// e.g. parallel copies of a stage.
+	QueueDepth int
+}
+
+// Metrics collects stats of a stage. It is safe to read while the pipeline runs
+// and may be shared by several stages, their stats are added up.
+type Metrics struct {
+	itemsIn     atomic.Int64
+	itemsOut    atomic.Int64
+	recvBlocked atomic.Int64
+	sendBlocked atomic.Int64
+
+	mu     sync.Mutex
+	queues map[*queueDepth]struct{}
+}
+
+// queueDepth reports the number of values in a queue.
+type queueDepth func() int
+
+func (m *Metrics) Stats() StageStats {
+	stats := StageStats{
+		ItemsIn:     m.itemsIn.Load(),
+		ItemsOut:    m.itemsOut.Load(),
+		RecvBlocked: time.Duration(m.recvBlocked.Load()),
+		SendBlocked: time.Duration(m.sendBlocked.Load()),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for depth := range m.queues {
+		stats.QueueDepth += (*depth)()
+	}
+	return stats
+}
+
+// watch adds the depth of a queue to the stats until the returned function is called.
+func (m *Metrics) watch(depth queueDepth) (unwatch func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.queues == nil {
+		m.queues = make(map[*queueDepth]struct{})
+	}
+	m.queues[&depth] = struct{}{}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.queues, &depth)
+	}
+}
+
+type StageOption func(*stageConfig)
+
+type stageConfig struct {
+	buffer  int
+	metrics *Metrics
+	clock   Clock
+}
+
+func newStageConfig(opts []StageOption) stageConfig {
+	cfg := stageConfig{clock: realClock{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithBuffer gives the stage an input queue of size values, so a bursty producer does not stall on it.
+func WithBuffer(size int) StageOption {
+	return func(c *stageConfig) {
+		c.buffer = size
+	}
+}
+
+// WithMetrics collects stats of the stage into m.
+func WithMetrics(m *Metrics) StageOption {
+	return func(c *stageConfig) {
+		c.metrics = m
+	}
+}
+
+// WithClock sets the clock of time-based stages and metrics, the wall clock by default.
+func WithClock(clock Clock) StageOption {
+	return func(c *stageConfig) {
+		c.clock = clock
+	}
+}
+
+// Configure makes a stage that runs stage with the given options.
+func Configure[I, O any](stage TypedStage[I, O], opts ...StageOption) TypedStage[I, O] {
+	cfg := newStageConfig(opts)
+	if cfg.buffer <= 0 && cfg.metrics == nil {
+		return stage
+	}
+	m := cfg.metrics
+	if m == nil {
+		m = &Metrics{}
+	}
+
+	clock := cfg.clock
+
+	return func(ctx context.Context, in <-chan I, out chan<- O) error {
+		g := newGroup(ctx)
+		defer g.cancel()
+
+		queue := make(chan I, max(cfg.buffer, 0))
+		defer m.watch(func() int { return len(queue) })()
+
+		g.run(func() error {
+			defer close(queue)
+			for {
+				start := clock.Now()
+				var v I
+				var ok bool
+				select {
+				case <-g.ctx.Done():
+				case v, ok = <-in:
+				}
+				m.recvBlocked.Add(int64(clock.Now().Sub(start)))
+				if !ok || !send(g.ctx, queue, v) {
+					return nil
+				}
+				m.itemsIn.Add(1)
+			}
+		})
+
+		results := make(chan O)
+		g.run(func() error {
+			if err := stage(g.ctx, queue, results); err != nil {
+				g.fail(err)
+			}
+			close(results)
+			return nil
+		})
+
+		for v := range results {
+			start := clock.Now()
+			sent := send(g.ctx, out, v)
+			// The time is counted even if the pipeline is cancelled while the stage is blocked.
+			m.sendBlocked.Add(int64(clock.Now().Sub(start)))
+			if !sent {
+				break
+			}
+			m.itemsOut.Add(1)
+		}
+		// The stage is done, so stop taking values for it.
+		g.stop()
+		return g.wait()
+	}
+}
@@ -0,0 +1,149 @@
+package hw06pipelineexecution
+
+import "context"
+
+// Order tells whether a parallel stage keeps values in input order.
+type Order uint8
+
+const (
+	// Unordered sends results as soon as any copy of the stage produces them.
+	Unordered Order = iota
+	// Ordered sends results in input order.
+	Ordered
+)
+
+func (o Order) String() string {
+	switch o {
+	case Unordered:
+		return "unordered"
+	case Ordered:
+		return "ordered"
+	default:
+		return "unknown"
+	}
+}
+
+// Parallel makes a stage that runs n copies of stage concurrently, fanning values out to them
+// and their results back in. The first error of any copy cancels all of them and is returned.
+// In ordered mode stage runs separately for every value and the results of a value are sent
+// after those of the values before it. So a stage may filter values or produce several results for one,
+// but a stage keeping state between values, like Batch, sees one value at a time.
+func Parallel[I, O any](stage TypedStage[I, O], n int, order Order) TypedStage[I, O] {
+	if n <= 1 {
+		return stage
+	}
+	if order == Ordered {
+		return ordered(perValue(stage), n)
+	}
+	return func(ctx context.Context, in <-chan I, out chan<- O) error {
+		g := newGroup(ctx)
+		defer g.cancel()
+
+		for i := 0; i < n; i++ {
+			g.run(func() error {
+				return stage(g.ctx, in, out)
+			})
+		}
+		return g.wait()
+	}
+}
+
+// ParallelMap makes a stage that applies fn to up to n values concurrently.
+// The first error of fn cancels the other calls and is returned.
+func ParallelMap[I, O any](fn func(ctx context.Context, v I) (O, error), n int, order Order) TypedStage[I, O] {
+	if n <= 1 {
+		return Map(fn)
+	}
+	if order == Ordered {
+		return ordered(func(ctx context.Context, v I) ([]O, error) {
+			res, err := fn(ctx, v)
+			return []O{res}, err
+		}, n)
+	}
+	return Parallel(Map(fn), n, Unordered)
+}
+
+// perValue makes a function that runs stage for a single value and collects its results.
+func perValue[I, O any](stage TypedStage[I, O]) func(ctx context.Context, v I) ([]O, error) {
+	return func(ctx context.Context, v I) ([]O, error) {
+		in := make(chan I, 1)
+		in <- v
+		close(in)
+
+		out := make(chan O)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- stage(ctx, in, out)
+			close(out)
+		}()
+
+		var results []O
+		for res := range out {
+			results = append(results, res)
+		}
+		return results, <-errc
+	}
+}
+
+// ordered runs fn for values in n goroutines and sends the results in the order values came.
+func ordered[I, O any](fn func(ctx context.Context, v I) ([]O, error), n int) TypedStage[I, O] {
+	type job struct {
+		v   I
+		res chan []O
+	}
+
+	return func(ctx context.Context, in <-chan I, out chan<- O) error {
+		g := newGroup(ctx)
+		defer g.cancel()
+
+		jobs := make(chan job)
+		// pending keeps result channels in input order, its size bounds the values in work.
+		pending := make(chan chan []O, n)
+		for i := 0; i < n; i++ {
+			g.run(func() error {
+				for j := range jobs {
+					res, err := fn(g.ctx, j.v)
+					if err != nil {
+						return err
+					}
+					j.res <- res
+				}
+				return nil
+			})
+		}
+
+		g.run(func() error {
+			defer close(jobs)
+			defer close(pending)
+
+			for v := range in {
+				res := make(chan []O, 1)
+				if !send(g.ctx, pending, res) || !send(g.ctx, jobs, job{v: v, res: res}) {
+					return nil
+				}
+			}
+			return nil
+		})
+
+		merge(g.ctx, pending, out)
+		// Nothing more is sent once merge has stopped, so stop the rest.
+		g.stop()
+		return g.wait()
+	}
+}
+
+// merge sends results from pending to out in turn until pending is closed or ctx is done.
+func merge[O any](ctx context.Context, pending <-chan chan []O, out chan<- O) {
+	for res := range pending {
+		select {
+		case <-ctx.Done():
+			return
+		case values := <-res:
+			for _, v := range values {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package hw06pipelineexecution
 
+import (
+	"context"
+	"errors"
+)
+
+var errValueType = errors.New("unexpected value type")
+
 type (
 	In  = <-chan interface{}
 	Out = In
@@ -8,40 +15,76 @@ type (
 
 type Stage func(in In) (out Out)
 
+// ExecutePipeline runs stages until in is closed or done is closed. Once done is closed,
+// the pipeline goroutines exit within a bounded time even if a stage never closes its output.
 func ExecutePipeline(in In, done In, stages ...Stage) Out {
-	for _, stage := range stages {
-		in = stage(work(in, done))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ctxStages := make([]ContextStage, len(stages))
+	for i, stage := range stages {
+		ctxStages[i] = Adapt(stage)
 	}
-	return work(in, done)
-}
+	out, errc := ExecutePipelineContext(ctx, in, ctxStages...)
 
-func work(in In, done In) In {
-	chNext := make(Bi)
 	go func() {
-		for {
-			select {
-			case <-done:
-				close(chNext)
-				for data := range in {
-					_ = data
-				}
-				return
-			case data, ok := <-in:
-				if !ok {
-					close(chNext)
-					return
-				}
+		defer cancel()
+		select {
+		case <-done:
+		case <-errc:
+		}
+	}()
+	return out
+}
+
+// Adapt runs stage as a ContextStage, e.g. to configure it for ExecutePipelineContext.
+// Once ctx is done the output of stage is drained in background for a bounded time,
+// so a stage that never closes it does not leak pipeline goroutines.
+func Adapt(stage Stage) ContextStage {
+	return func(ctx context.Context, in In, out chan<- interface{}) error {
+		return forward(ctx, stage(in), out)
+	}
+}
+
+// AsStage runs stage as a Stage of ExecutePipeline until done is closed, e.g. to use Batch there.
+// Stage cannot report errors, so an error of stage or a value that is not I just stops it, closing its output.
+func AsStage[I, O any](stage TypedStage[I, O], done In) Stage {
+	typed := Map(func(_ context.Context, v interface{}) (I, error) {
+		typed, ok := v.(I)
+		if !ok {
+			return typed, errValueType
+		}
+		return typed, nil
+	})
+	untyped := Map(func(_ context.Context, v O) (interface{}, error) {
+		return v, nil
+	})
+
+	return func(in In) Out {
+		ctx, cancel := context.WithCancel(context.Background())
+		results, _ := Execute(ctx, in, Chain(Chain(typed, stage), untyped))
+
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			// Cancelling stops the stage and makes it drain in for a bounded time.
+			defer cancel()
+
+			for {
 				select {
 				case <-done:
-					close(chNext)
-					for data := range in {
-						_ = data
-					}
 					return
-				case chNext <- data:
+				case v, ok := <-results:
+					if !ok {
+						return
+					}
+					select {
+					case <-done:
+						return
+					case out <- v:
+					}
 				}
 			}
-		}
-	}()
-	return chNext
+		}()
+		return out
+	}
 }
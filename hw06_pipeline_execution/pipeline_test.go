@@ -1,8 +1,11 @@
 package hw06pipelineexecution
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -164,3 +167,672 @@ func TestAllStageStop(t *testing.T) {
 		require.Len(t, result, 0)
 	})
 }
+
+func TestExecutePipelineContext(t *testing.T) {
+	feed := func(data ...interface{}) In {
+		in := make(Bi)
+		go func() {
+			defer close(in)
+			for _, v := range data {
+				in <- v
+			}
+		}()
+		return in
+	}
+	collect := func(out Out, errc <-chan error) ([]interface{}, error) {
+		var result []interface{}
+		for v := range out {
+			result = append(result, v)
+		}
+		return result, <-errc
+	}
+	sleepy := func(f func(v interface{}) (interface{}, error)) ContextStage {
+		return Map(func(ctx context.Context, v interface{}) (interface{}, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(sleepPerStage):
+			}
+			return f(v)
+		})
+	}
+
+	stages := []ContextStage{
+		sleepy(func(v interface{}) (interface{}, error) { return v, nil }),
+		sleepy(func(v interface{}) (interface{}, error) { return v.(int) * 2, nil }),
+		sleepy(func(v interface{}) (interface{}, error) { return v.(int) + 100, nil }),
+		sleepy(func(v interface{}) (interface{}, error) { return strconv.Itoa(v.(int)), nil }),
+	}
+
+	t.Run("simple case", func(t *testing.T) {
+		start := time.Now()
+		result, err := collect(ExecutePipelineContext(context.Background(), feed(1, 2, 3, 4, 5), stages...))
+		elapsed := time.Since(start)
+
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"102", "104", "106", "108", "110"}, result)
+		require.Less(t, int64(elapsed), int64(sleepPerStage)*int64(len(stages)+4)+int64(fault))
+	})
+
+	t.Run("no stages", func(t *testing.T) {
+		result, err := collect(ExecutePipelineContext(context.Background(), feed(1, 2)))
+
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{1, 2}, result)
+	})
+
+	t.Run("stage error cancels pipeline", func(t *testing.T) {
+		errOdd := errors.New("odd value")
+		var stopped sync.WaitGroup
+		tracked := func(stage ContextStage) ContextStage {
+			stopped.Add(1)
+			return func(ctx context.Context, in In, out chan<- interface{}) error {
+				defer stopped.Done()
+				return stage(ctx, in, out)
+			}
+		}
+
+		data := make([]interface{}, 100)
+		for i := range data {
+			data[i] = i
+		}
+
+		out, errc := ExecutePipelineContext(context.Background(), feed(data...),
+			tracked(Map(func(_ context.Context, v interface{}) (interface{}, error) { return v, nil })),
+			tracked(Map(func(_ context.Context, v interface{}) (interface{}, error) {
+				if v.(int) == 3 {
+					return nil, errOdd
+				}
+				return v, nil
+			})),
+			tracked(Map(func(_ context.Context, v interface{}) (interface{}, error) { return v, nil })),
+		)
+		result, err := collect(out, errc)
+		stopped.Wait()
+
+		require.ErrorIs(t, err, errOdd)
+		// Values before the failed one may be dropped by cancellation, but nothing after it passes.
+		require.LessOrEqual(t, len(result), 3)
+		require.Equal(t, data[:len(result)], result)
+	})
+
+	t.Run("only first error is returned", func(t *testing.T) {
+		errFirst := errors.New("first")
+		errSecond := errors.New("second")
+		failing := func(err error, wait time.Duration) ContextStage {
+			return func(context.Context, In, chan<- interface{}) error {
+				time.Sleep(wait)
+				return err
+			}
+		}
+
+		_, err := collect(ExecutePipelineContext(context.Background(), feed(1),
+			failing(errSecond, sleepPerStage), failing(errFirst, 0)))
+
+		require.ErrorIs(t, err, errFirst)
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), sleepPerStage*2)
+		defer cancel()
+
+		start := time.Now()
+		result, err := collect(ExecutePipelineContext(ctx, feed(1, 2, 3, 4, 5), stages...))
+		elapsed := time.Since(start)
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Empty(t, result)
+		require.Less(t, int64(elapsed), int64(sleepPerStage)*2+int64(fault))
+	})
+
+	t.Run("early returning stage stops stages before it", func(t *testing.T) {
+		var stopped sync.WaitGroup
+		stopped.Add(1)
+		endless := func(ctx context.Context, _ In, out chan<- interface{}) error {
+			defer stopped.Done()
+			for i := 0; ; i++ {
+				if !send(ctx, out, interface{}(i)) {
+					return nil
+				}
+			}
+		}
+		first := func(_ context.Context, in In, out chan<- interface{}) error {
+			out <- <-in
+			return nil
+		}
+
+		result, err := collect(ExecutePipelineContext(context.Background(), feed(), endless, first))
+		stopped.Wait()
+
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{0}, result)
+	})
+}
+
+func TestTypedPipeline(t *testing.T) {
+	feed := func(data ...int) <-chan int {
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			for _, v := range data {
+				in <- v
+			}
+		}()
+		return in
+	}
+
+	double := Map(func(_ context.Context, v int) (int, error) { return v * 2, nil })
+	format := Map(func(_ context.Context, v int) (string, error) { return "#" + strconv.Itoa(v), nil })
+	length := Map(func(_ context.Context, v string) (int, error) { return len(v), nil })
+
+	t.Run("heterogeneous stages", func(t *testing.T) {
+		out, errc := Execute(context.Background(), feed(1, 5, 50), Chain(Chain(double, format), length))
+
+		var result []int
+		for v := range out {
+			result = append(result, v)
+		}
+		require.NoError(t, <-errc)
+		require.Equal(t, []int{2, 3, 4}, result)
+	})
+
+	t.Run("error of first stage", func(t *testing.T) {
+		errNegative := errors.New("negative value")
+		check := Map(func(_ context.Context, v int) (int, error) {
+			if v < 0 {
+				return 0, errNegative
+			}
+			return v, nil
+		})
+
+		out, errc := Execute(context.Background(), feed(1, -1, 2), Chain(check, format))
+
+		var result []string
+		for v := range out {
+			result = append(result, v)
+		}
+		require.ErrorIs(t, <-errc, errNegative)
+		require.LessOrEqual(t, len(result), 1)
+	})
+
+	t.Run("error of second stage wins over cancellation of first", func(t *testing.T) {
+		errTooLong := errors.New("too long")
+		check := Map(func(_ context.Context, v string) (string, error) {
+			if len(v) > 2 {
+				return "", errTooLong
+			}
+			return v, nil
+		})
+		waiting := Map(func(ctx context.Context, v int) (int, error) {
+			if v > 10 {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return v, nil
+		})
+
+		out, errc := Execute(context.Background(), feed(10, 100), Chain(Chain(waiting, format), check))
+
+		for v := range out {
+			_ = v
+		}
+		require.ErrorIs(t, <-errc, errTooLong)
+	})
+
+	t.Run("first stage is stopped when second returns", func(t *testing.T) {
+		stopped := make(chan struct{})
+		naturals := func(ctx context.Context, _ <-chan int, out chan<- int) error {
+			defer close(stopped)
+			for i := 1; ; i++ {
+				if !send(ctx, out, i) {
+					return ctx.Err()
+				}
+			}
+		}
+		take := func(n int) TypedStage[int, int] {
+			return func(_ context.Context, in <-chan int, out chan<- int) error {
+				for i := 0; i < n; i++ {
+					out <- <-in
+				}
+				return nil
+			}
+		}
+
+		out, errc := Execute(context.Background(), feed(), Chain(Chain(naturals, take(3)), double))
+
+		var result []int
+		for v := range out {
+			result = append(result, v)
+		}
+		<-stopped
+		require.NoError(t, <-errc)
+		require.Equal(t, []int{2, 4, 6}, result)
+	})
+}
+
+func TestParallelStage(t *testing.T) {
+	const (
+		workers = 5
+		count   = 20
+	)
+
+	feed := func() <-chan int {
+		in := make(chan int)
+		go func() {
+			defer close(in)
+			for i := 0; i < count; i++ {
+				in <- i
+			}
+		}()
+		return in
+	}
+	slow := func(ctx context.Context, v int) (int, error) {
+		// Later values are faster, so unordered calls would reorder them.
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Duration(count-v) * time.Millisecond * 5):
+		}
+		return v * v, nil
+	}
+	collect := func(out <-chan int, errc <-chan error) ([]int, error) {
+		var result []int
+		for v := range out {
+			result = append(result, v)
+		}
+		return result, <-errc
+	}
+
+	expected := make([]int, count)
+	for i := range expected {
+		expected[i] = i * i
+	}
+
+	t.Run("ordered", func(t *testing.T) {
+		start := time.Now()
+		result, err := collect(Execute(context.Background(), feed(), ParallelMap(slow, workers, Ordered)))
+		elapsed := time.Since(start)
+
+		require.NoError(t, err)
+		require.Equal(t, expected, result)
+		// Sequentially the stage takes count*(count+1)/2*5ms = 1.05s.
+		require.Less(t, elapsed, 500*time.Millisecond)
+	})
+
+	t.Run("unordered", func(t *testing.T) {
+		start := time.Now()
+		result, err := collect(Execute(context.Background(), feed(), ParallelMap(slow, workers, Unordered)))
+		elapsed := time.Since(start)
+
+		require.NoError(t, err)
+		require.ElementsMatch(t, expected, result)
+		require.Less(t, elapsed, 500*time.Millisecond)
+	})
+
+	t.Run("single copy", func(t *testing.T) {
+		result, err := collect(Execute(context.Background(), feed(), ParallelMap(slow, 1, Ordered)))
+
+		require.NoError(t, err)
+		require.Equal(t, expected, result)
+	})
+
+	t.Run("copies of filtering stage", func(t *testing.T) {
+		evens := func(ctx context.Context, in <-chan int, out chan<- int) error {
+			for v := range in {
+				if v%2 == 0 && !send(ctx, out, v) {
+					return nil
+				}
+			}
+			return nil
+		}
+
+		result, err := collect(Execute(context.Background(), feed(), Parallel(evens, workers, Unordered)))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18}, result)
+
+		result, err = collect(Execute(context.Background(), feed(), Parallel(evens, workers, Ordered)))
+		require.NoError(t, err)
+		require.Equal(t, []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18}, result)
+	})
+
+	t.Run("error stops all copies", func(t *testing.T) {
+		errSeven := errors.New("seven")
+		var running sync.WaitGroup
+		failing := func(ctx context.Context, in <-chan int, out chan<- int) error {
+			running.Add(1)
+			defer running.Done()
+			for v := range in {
+				if v == 7 {
+					return errSeven
+				}
+				if !send(ctx, out, v) {
+					return ctx.Err()
+				}
+			}
+			return nil
+		}
+
+		result, err := collect(Execute(context.Background(), feed(), Parallel(failing, workers, Unordered)))
+		running.Wait()
+
+		require.ErrorIs(t, err, errSeven)
+		require.NotContains(t, result, 7)
+	})
+
+	for _, order := range []Order{Ordered, Unordered} {
+		t.Run("error stops all calls "+order.String(), func(t *testing.T) {
+			errSeven := errors.New("seven")
+			var running atomic.Int32
+			failing := func(ctx context.Context, v int) (int, error) {
+				running.Add(1)
+				defer running.Add(-1)
+				if v == 7 {
+					return 0, errSeven
+				}
+				return slow(ctx, v)
+			}
+
+			result, err := collect(Execute(context.Background(), feed(), ParallelMap(failing, workers, order)))
+
+			require.ErrorIs(t, err, errSeven)
+			require.Zero(t, running.Load())
+			require.NotContains(t, result, 49)
+		})
+	}
+
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		result, err := collect(Execute(ctx, feed(), ParallelMap(slow, workers, Ordered)))
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Empty(t, result)
+	})
+}
+
+func TestParallelOrderedStage(t *testing.T) {
+	const count = 10
+
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; i < count; i++ {
+			in <- i
+		}
+	}()
+	// The stage sends each value and its negation, later values are faster.
+	twice := func(ctx context.Context, in <-chan int, out chan<- int) error {
+		for v := range in {
+			time.Sleep(time.Duration(count-v) * time.Millisecond)
+			if !send(ctx, out, v) || !send(ctx, out, -v) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	out, errc := Execute(context.Background(), in, Parallel(twice, 4, Ordered))
+	var result []int
+	for v := range out {
+		result = append(result, v)
+	}
+
+	require.NoError(t, <-errc)
+	require.Len(t, result, 2*count)
+	for i := 0; i < count; i++ {
+		require.Equal(t, []int{i, -i}, result[2*i:2*i+2])
+	}
+}
+
+func TestConfiguredStage(t *testing.T) {
+	const count = 10
+
+	feed := func() (<-chan int, <-chan time.Duration) {
+		in := make(chan int)
+		took := make(chan time.Duration, 1)
+		go func() {
+			defer close(in)
+			start := time.Now()
+			for i := 0; i < count; i++ {
+				in <- i
+			}
+			took <- time.Since(start)
+		}()
+		return in, took
+	}
+
+	t.Run("buffer absorbs bursts", func(t *testing.T) {
+		gate := make(chan struct{})
+		gated := Map(func(_ context.Context, v int) (int, error) {
+			<-gate
+			return v, nil
+		})
+		in, took := feed()
+
+		var m Metrics
+		out, errc := Execute(context.Background(), in, Configure(gated, WithBuffer(count), WithMetrics(&m)))
+
+		// The producer is done while the stage has not processed anything yet.
+		producerTime := <-took
+		// One value is held by the stage itself.
+		require.Eventually(t, func() bool {
+			stats := m.Stats()
+			return stats.ItemsIn == count && stats.QueueDepth == count-1
+		}, time.Second, time.Millisecond)
+		require.Less(t, producerTime, time.Second)
+
+		close(gate)
+		var result []int
+		for v := range out {
+			result = append(result, v)
+		}
+		require.NoError(t, <-errc)
+		require.Len(t, result, count)
+
+		stats := m.Stats()
+		require.Equal(t, int64(count), stats.ItemsIn)
+		require.Equal(t, int64(count), stats.ItemsOut)
+		require.Zero(t, stats.QueueDepth)
+	})
+
+	t.Run("metrics shared by parallel copies", func(t *testing.T) {
+		gate := make(chan struct{})
+		gated := Map(func(_ context.Context, v int) (int, error) {
+			<-gate
+			return v, nil
+		})
+		in, _ := feed()
+
+		var m Metrics
+		stage := Parallel(Configure(gated, WithBuffer(count), WithMetrics(&m)), 2, Unordered)
+		out, errc := Execute(context.Background(), in, stage)
+
+		// A copy that has got a value holds one itself, the rest are queued in the queues of both copies.
+		require.Eventually(t, func() bool {
+			stats := m.Stats()
+			return stats.ItemsIn == count && stats.QueueDepth < count
+		}, time.Second, time.Millisecond)
+		require.GreaterOrEqual(t, m.Stats().QueueDepth, count-2)
+
+		close(gate)
+		for v := range out {
+			_ = v
+		}
+		require.NoError(t, <-errc)
+
+		stats := m.Stats()
+		require.Equal(t, int64(count), stats.ItemsIn)
+		require.Equal(t, int64(count), stats.ItemsOut)
+		require.Zero(t, stats.QueueDepth)
+	})
+
+	t.Run("stage error", func(t *testing.T) {
+		errStage := errors.New("stage failed")
+		failing := Map(func(_ context.Context, v int) (int, error) {
+			if v == 3 {
+				return 0, errStage
+			}
+			return v, nil
+		})
+		in, _ := feed()
+
+		out, errc := Execute(context.Background(), in, Configure(failing, WithBuffer(2)))
+		for v := range out {
+			_ = v
+		}
+		require.ErrorIs(t, <-errc, errStage)
+	})
+
+	t.Run("stage returning early", func(t *testing.T) {
+		first := func(_ context.Context, in <-chan int, out chan<- int) error {
+			out <- <-in
+			return nil
+		}
+		in, _ := feed()
+
+		out, errc := Execute(context.Background(), in, Configure(first, WithBuffer(3)))
+		var result []int
+		for v := range out {
+			result = append(result, v)
+		}
+		require.NoError(t, <-errc)
+		require.Equal(t, []int{0}, result)
+	})
+}
+
+// watchedClock is a fakeClock that reports every reading of the time.
+type watchedClock struct {
+	*fakeClock
+	reads chan struct{}
+}
+
+func newWatchedClock() *watchedClock {
+	return &watchedClock{fakeClock: &fakeClock{}, reads: make(chan struct{}, 100)}
+}
+
+func (c *watchedClock) Now() time.Time {
+	now := c.fakeClock.Now()
+	c.reads <- struct{}{}
+	return now
+}
+
+// waitReads waits until the time has been read n times.
+func (c *watchedClock) waitReads(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.reads:
+		case <-time.After(time.Second):
+			require.FailNow(t, "time is not read")
+		}
+	}
+}
+
+func TestStageMetricsTime(t *testing.T) {
+	identity := Map(func(_ context.Context, v int) (int, error) { return v, nil })
+
+	t.Run("blocked receive", func(t *testing.T) {
+		clock := newWatchedClock()
+		in := make(chan int)
+		var m Metrics
+		out, errc := Execute(context.Background(), in, Configure(identity, WithMetrics(&m), WithClock(clock)))
+
+		// The stage waits for a value.
+		clock.waitReads(t, 1)
+		clock.Advance(time.Second)
+		in <- 1
+		require.Equal(t, 1, <-out)
+		close(in)
+		for v := range out {
+			_ = v
+		}
+		require.NoError(t, <-errc)
+
+		stats := m.Stats()
+		require.Equal(t, time.Second, stats.RecvBlocked)
+		require.Zero(t, stats.SendBlocked)
+	})
+
+	for _, cancelled := range []bool{false, true} {
+		name := "blocked send"
+		if cancelled {
+			name += " until cancellation"
+		}
+		t.Run(name, func(t *testing.T) {
+			clock := newWatchedClock()
+			in := make(chan int, 1)
+			in <- 1
+			close(in)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var m Metrics
+			out, errc := Execute(ctx, in, Configure(identity, WithMetrics(&m), WithClock(clock)))
+
+			// The value and the end of in are received, and then the stage waits to send the result.
+			clock.waitReads(t, 5)
+			clock.Advance(time.Second)
+			if cancelled {
+				cancel()
+			}
+			for v := range out {
+				_ = v
+			}
+			if cancelled {
+				require.ErrorIs(t, <-errc, context.Canceled)
+			} else {
+				require.NoError(t, <-errc)
+			}
+
+			stats := m.Stats()
+			require.Equal(t, time.Second, stats.SendBlocked)
+			require.Zero(t, stats.RecvBlocked)
+		})
+	}
+}
+
+func TestAdaptedStage(t *testing.T) {
+	const count = 10
+
+	gate := make(chan struct{})
+	double := func(in In) Out {
+		out := make(Bi)
+		go func() {
+			defer close(out)
+			for v := range in {
+				<-gate
+				out <- v.(int) * 2
+			}
+		}()
+		return out
+	}
+	in := make(Bi)
+	go func() {
+		defer close(in)
+		for i := 0; i < count; i++ {
+			in <- i
+		}
+	}()
+
+	var m Metrics
+	out, errc := ExecutePipelineContext(context.Background(), in,
+		Configure(Adapt(double), WithBuffer(count), WithMetrics(&m)), Adapt(double))
+
+	// One value is held by the stage itself.
+	require.Eventually(t, func() bool {
+		stats := m.Stats()
+		return stats.ItemsIn == count && stats.QueueDepth == count-1
+	}, time.Second, time.Millisecond)
+
+	close(gate)
+	var result []int
+	for v := range out {
+		result = append(result, v.(int))
+	}
+	require.NoError(t, <-errc)
+	require.Len(t, result, count)
+	for i, v := range result {
+		require.Equal(t, i*4, v)
+	}
+	require.Equal(t, int64(count), m.Stats().ItemsOut)
+}
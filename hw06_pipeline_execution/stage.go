@@ -0,0 +1,178 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// drainTimeout bounds how long a stopped pipeline keeps draining a channel it no longer needs,
+// so a stuck or endless sender does not keep the pipeline goroutine forever.
+const drainTimeout = 500 * time.Millisecond
+
+// TypedStage reads values from in and sends results to out until in is closed.
+// It must return once ctx is done, and returning an error stops the whole pipeline.
+// The pipeline closes out after the stage returns.
+type TypedStage[I, O any] func(ctx context.Context, in <-chan I, out chan<- O) error
+
+// Map makes a stage that applies fn to every value.
+func Map[I, O any](fn func(ctx context.Context, v I) (O, error)) TypedStage[I, O] {
+	return func(ctx context.Context, in <-chan I, out chan<- O) error {
+		for v := range in {
+			res, err := fn(ctx, v)
+			if err != nil {
+				return err
+			}
+			if !send(ctx, out, res) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+// Chain makes a stage that runs first and second concurrently, feeding the output of first to second.
+// The first error of either of them cancels both and is returned. When second returns early,
+// first is cancelled and its result is ignored.
+func Chain[A, B, C any](first TypedStage[A, B], second TypedStage[B, C]) TypedStage[A, C] {
+	return func(ctx context.Context, in <-chan A, out chan<- C) error {
+		g := newGroup(ctx)
+		defer g.cancel()
+		firstCtx, cancelFirst := context.WithCancel(g.ctx)
+		defer cancelFirst()
+
+		var secondDone atomic.Bool
+		mid := make(chan B)
+		g.wg.Add(1)
+		go func() {
+			defer g.wg.Done()
+			defer close(mid)
+
+			if err := first(firstCtx, in, mid); err != nil && !secondDone.Load() {
+				g.fail(err)
+			}
+		}()
+
+		err := second(g.ctx, mid, out)
+		secondDone.Store(true)
+		if err != nil {
+			g.fail(err)
+		}
+		cancelFirst()
+		return g.wait()
+	}
+}
+
+// Execute runs stage in its own goroutine, usually a chain of stages.
+// The first stage error cancels all stages and is sent to the returned error channel,
+// which is closed after the output once every stage has returned. If ctx is done first, its error is sent instead.
+// When the pipeline stops early in is drained in background for a bounded time,
+// a sender that keeps sending after that blocks.
+// The output must be read until it is closed or ctx must be cancelled.
+func Execute[I, O any](ctx context.Context, in <-chan I, stage TypedStage[I, O]) (<-chan O, <-chan error) {
+	out := make(chan O)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+
+		err := Chain(forward[I], stage)(ctx, in, out)
+		close(out)
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return out, errc
+}
+
+// forward passes values from in to out until ctx is done and then drains in.
+func forward[T any](ctx context.Context, in <-chan T, out chan<- T) error {
+	for {
+		select {
+		case <-ctx.Done():
+			go drain(in)
+			return nil
+		case v, ok := <-in:
+			if !ok {
+				return nil
+			}
+			if !send(ctx, out, v) {
+				go drain(in)
+				return nil
+			}
+		}
+	}
+}
+
+// send reports false if ctx is done before v is sent.
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- v:
+		return true
+	}
+}
+
+// drain reads in until it is closed or drainTimeout passes, so its sender can finish its work.
+func drain[T any](in <-chan T) {
+	timer := time.NewTimer(drainTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return
+		case _, ok := <-in:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// group tracks goroutines of stages, the first error cancels all of them.
+type group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	once sync.Once
+	err  error
+}
+
+func newGroup(ctx context.Context) *group {
+	ctx, cancel := context.WithCancel(ctx)
+	return &group{ctx: ctx, cancel: cancel}
+}
+
+func (g *group) fail(err error) {
+	g.once.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
+
+// stop cancels the group without an error, errors that follow are ignored.
+func (g *group) stop() {
+	g.fail(nil)
+}
+
+// run starts fn in a goroutine of the group.
+func (g *group) run(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *group) wait() error {
+	g.wg.Wait()
+	return g.err
+}
@@ -0,0 +1,239 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"time"
+)
+
+// Batch makes a stage that groups values into batches, sent once they have size values if size is positive
+// or once wait has passed since their first value if wait is positive. What is left is sent when in is closed.
+// At least one of size and wait must be positive, otherwise batches would grow until in is closed,
+// so Batch panics.
+func Batch[T any](size int, wait time.Duration, opts ...StageOption) TypedStage[T, []T] {
+	if size <= 0 && wait <= 0 {
+		panic("pipeline: batch needs positive size or wait")
+	}
+	clock := newStageConfig(opts).clock
+	stage := func(ctx context.Context, in <-chan T, out chan<- []T) error {
+		var batch []T
+		var timer Timer
+		stopTimer := func() {
+			if timer != nil {
+				timer.Stop()
+				timer = nil
+			}
+		}
+		defer stopTimer()
+		flush := func() bool {
+			stopTimer()
+			if len(batch) == 0 {
+				return true
+			}
+			full := batch
+			batch = nil
+			return send(ctx, out, full)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return nil
+				}
+				if fired(timer) && !flush() {
+					return nil
+				}
+				batch = append(batch, v)
+				if size > 0 && len(batch) >= size {
+					if !flush() {
+						return nil
+					}
+				} else if wait > 0 && timer == nil {
+					timer = clock.NewTimer(wait)
+				}
+			case <-timerC(timer):
+				timer = nil
+				if !flush() {
+					return nil
+				}
+			}
+		}
+	}
+	return Configure(stage, opts...)
+}
+
+// TumblingWindow makes a stage that groups values by consecutive windows of size,
+// the first one starting with the stage. Windows are sent as they end, empty ones are skipped.
+// What is left is sent when in is closed.
+func TumblingWindow[T any](size time.Duration, opts ...StageOption) TypedStage[T, []T] {
+	return SlidingWindow[T](size, size, opts...)
+}
+
+// SlidingWindow makes a stage that sends the values received during the last size every period,
+// so a value is sent in several windows if period is less than size.
+// Windows without values received since the previous window are skipped.
+// When in is closed the values of the unfinished window are sent the same way.
+func SlidingWindow[T any](size, period time.Duration, opts ...StageOption) TypedStage[T, []T] {
+	clock := newStageConfig(opts).clock
+	type entry struct {
+		at time.Time
+		v  T
+	}
+
+	stage := func(ctx context.Context, in <-chan T, out chan<- []T) error {
+		var entries []entry
+		last := clock.Now()
+		// window returns values received in [start, end) if some of them came after the previous window.
+		window := func(start, end time.Time) []T {
+			for len(entries) > 0 && entries[0].at.Before(start) {
+				entries = entries[1:]
+			}
+			var values []T
+			fresh := false
+			for _, e := range entries {
+				if !e.at.Before(end) {
+					break
+				}
+				values = append(values, e.v)
+				fresh = fresh || !e.at.Before(last)
+			}
+			last = end
+			if !fresh {
+				return nil
+			}
+			return values
+		}
+
+		end := last.Add(period)
+		timer := clock.NewTimer(period)
+		defer func() { timer.Stop() }()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case v, ok := <-in:
+				if !ok {
+					// The unfinished window ends right after now to include values received just now.
+					if values := window(end.Add(-size), clock.Now().Add(1)); values != nil {
+						send(ctx, out, values)
+					}
+					return nil
+				}
+				entries = append(entries, entry{at: clock.Now(), v: v})
+			case <-timer.C():
+				// The next window is scheduled before sending, so a slow receiver does not shift windows.
+				values := window(end.Add(-size), end)
+				end = end.Add(period)
+				timer = clock.NewTimer(end.Sub(clock.Now()))
+				if values != nil && !send(ctx, out, values) {
+					return nil
+				}
+			}
+		}
+	}
+	return Configure(stage, opts...)
+}
+
+// Debounce makes a stage that sends a value only once no other value has come for quiet,
+// dropping the values replaced before that. The pending value is sent when in is closed.
+func Debounce[T any](quiet time.Duration, opts ...StageOption) TypedStage[T, T] {
+	clock := newStageConfig(opts).clock
+	stage := func(ctx context.Context, in <-chan T, out chan<- T) error {
+		var pending T
+		var timer Timer
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case v, ok := <-in:
+				if !ok {
+					if timer != nil {
+						send(ctx, out, pending)
+					}
+					return nil
+				}
+				if fired(timer) && !send(ctx, out, pending) {
+					return nil
+				}
+				pending = v
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = clock.NewTimer(quiet)
+			case <-timerC(timer):
+				timer = nil
+				if !send(ctx, out, pending) {
+					return nil
+				}
+			}
+		}
+	}
+	return Configure(stage, opts...)
+}
+
+// Throttle makes a stage that sends at most one value per interval,
+// values that come sooner than interval after the last sent one are dropped.
+func Throttle[T any](interval time.Duration, opts ...StageOption) TypedStage[T, T] {
+	clock := newStageConfig(opts).clock
+	stage := func(ctx context.Context, in <-chan T, out chan<- T) error {
+		var cooldown Timer
+		defer func() {
+			if cooldown != nil {
+				cooldown.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case v, ok := <-in:
+				if !ok {
+					return nil
+				}
+				if fired(cooldown) {
+					cooldown = nil
+				}
+				if cooldown != nil {
+					continue
+				}
+				if !send(ctx, out, v) {
+					return nil
+				}
+				cooldown = clock.NewTimer(interval)
+			case <-timerC(cooldown):
+				cooldown = nil
+			}
+		}
+	}
+	return Configure(stage, opts...)
+}
+
+// fired reports whether timer has fired, taking the value from its channel.
+// A stage checks it on receiving a value, as select may pick the value over the timer.
+func fired(timer Timer) bool {
+	select {
+	case <-timerC(timer):
+		return true
+	default:
+		return false
+	}
+}
+
+// timerC returns the channel of timer, or nil that blocks forever if there is no timer.
+func timerC(timer Timer) <-chan time.Time {
+	if timer == nil {
+		return nil
+	}
+	return timer.C()
+}
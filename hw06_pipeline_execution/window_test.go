@@ -0,0 +1,411 @@
+package hw06pipelineexecution
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock fires timers only when advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	c     chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Timers returns the number of timers waiting to fire.
+func (c *fakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Advance moves the time forward and fires the timers that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	sort.Slice(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+	for len(c.timers) > 0 && !c.timers[0].at.After(c.now) {
+		c.timers[0].c <- c.timers[0].at
+		c.timers = c.timers[1:]
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// stageRun drives a stage directly, so a value is taken by the stage itself once it is sent.
+type stageRun[I, O any] struct {
+	t      *testing.T
+	clock  *fakeClock
+	in     chan I
+	out    chan O
+	done   chan error
+	cancel context.CancelFunc
+}
+
+func startStage[I, O any](t *testing.T, newStage func(opts ...StageOption) TypedStage[I, O]) *stageRun[I, O] {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &stageRun[I, O]{
+		t:      t,
+		clock:  &fakeClock{},
+		in:     make(chan I),
+		out:    make(chan O, 100),
+		done:   make(chan error, 1),
+		cancel: cancel,
+	}
+	stage := newStage(WithClock(r.clock))
+	go func() {
+		r.done <- stage(ctx, r.in, r.out)
+		close(r.out)
+	}()
+	t.Cleanup(cancel)
+	return r
+}
+
+func (r *stageRun[I, O]) send(values ...I) {
+	for _, v := range values {
+		r.in <- v
+	}
+}
+
+// advance moves the clock once the stage has set the given number of timers.
+func (r *stageRun[I, O]) advance(timers int, d time.Duration) {
+	r.t.Helper()
+	require.Eventually(r.t, func() bool { return r.clock.Timers() == timers }, time.Second, time.Millisecond)
+	r.clock.Advance(d)
+}
+
+func (r *stageRun[I, O]) receive() O {
+	r.t.Helper()
+	select {
+	case v := <-r.out:
+		return v
+	case <-time.After(time.Second):
+		require.FailNow(r.t, "no value from stage")
+		var zero O
+		return zero
+	}
+}
+
+// requireNothing checks that the stage has not sent anything after it has set the given number of timers.
+func (r *stageRun[I, O]) requireNothing(timers int) {
+	r.t.Helper()
+	require.Eventually(r.t, func() bool { return r.clock.Timers() == timers }, time.Second, time.Millisecond)
+	select {
+	case v := <-r.out:
+		require.FailNow(r.t, "unexpected value from stage", "%v", v)
+	default:
+	}
+}
+
+func (r *stageRun[I, O]) close() []O {
+	r.t.Helper()
+	close(r.in)
+	var rest []O
+	for v := range r.out {
+		rest = append(rest, v)
+	}
+	require.NoError(r.t, <-r.done)
+	return rest
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("by count", func(t *testing.T) {
+		r := startStage(t, func(opts ...StageOption) TypedStage[int, []int] { return Batch[int](3, 0, opts...) })
+
+		r.send(1, 2, 3, 4, 5, 6, 7)
+		require.Equal(t, []int{1, 2, 3}, r.receive())
+		require.Equal(t, []int{4, 5, 6}, r.receive())
+		require.Equal(t, [][]int{{7}}, r.close())
+	})
+
+	t.Run("by time", func(t *testing.T) {
+		r := startStage(t, func(opts ...StageOption) TypedStage[int, []int] {
+			return Batch[int](10, time.Second, opts...)
+		})
+
+		r.send(1, 2)
+		r.advance(1, 500*time.Millisecond)
+		r.send(3)
+		r.requireNothing(1)
+		r.advance(1, 500*time.Millisecond)
+		require.Equal(t, []int{1, 2, 3}, r.receive())
+
+		// The timer starts with the first value of a batch.
+		r.advance(0, 10*time.Second)
+		r.send(4)
+		r.advance(1, 999*time.Millisecond)
+		r.requireNothing(1)
+		r.advance(1, time.Millisecond)
+		require.Equal(t, []int{4}, r.receive())
+		require.Empty(t, r.close())
+	})
+
+	t.Run("full batch resets timer", func(t *testing.T) {
+		r := startStage(t, func(opts ...StageOption) TypedStage[int, []int] {
+			return Batch[int](2, time.Second, opts...)
+		})
+
+		r.send(1, 2)
+		require.Equal(t, []int{1, 2}, r.receive())
+		r.requireNothing(0)
+		r.send(3)
+		r.advance(1, time.Second)
+		require.Equal(t, []int{3}, r.receive())
+		require.Empty(t, r.close())
+	})
+
+	t.Run("without size and wait", func(t *testing.T) {
+		require.Panics(t, func() { Batch[int](0, 0) })
+	})
+}
+
+func TestTumblingWindow(t *testing.T) {
+	t.Run("consecutive windows", func(t *testing.T) {
+		r := startStage(t, func(opts ...StageOption) TypedStage[int, []int] {
+			return TumblingWindow[int](time.Second, opts...)
+		})
+
+		r.send(1, 2)
+		r.advance(1, 500*time.Millisecond)
+		r.send(3)
+		r.advance(1, 500*time.Millisecond)
+		require.Equal(t, []int{1, 2, 3}, r.receive())
+
+		// A value at the end of a window belongs to the next one.
+		r.send(4)
+		r.advance(1, time.Second)
+		require.Equal(t, []int{4}, r.receive())
+
+		// Empty windows are skipped.
+		r.advance(1, time.Second)
+		r.advance(1, time.Second)
+		r.send(5)
+		r.requireNothing(1)
+		require.Equal(t, [][]int{{5}}, r.close())
+	})
+
+	t.Run("values are not sent again on close", func(t *testing.T) {
+		r := startStage(t, func(opts ...StageOption) TypedStage[int, []int] {
+			return TumblingWindow[int](time.Second, opts...)
+		})
+
+		r.advance(1, 800*time.Millisecond)
+		r.send(1)
+		r.advance(1, 200*time.Millisecond)
+		require.Equal(t, []int{1}, r.receive())
+		r.advance(1, 500*time.Millisecond)
+		r.send(2)
+		require.Equal(t, [][]int{{2}}, r.close())
+	})
+}
+
+func TestSlidingWindow(t *testing.T) {
+	r := startStage(t, func(opts ...StageOption) TypedStage[int, []int] {
+		return SlidingWindow[int](3*time.Second, time.Second, opts...)
+	})
+
+	r.send(1)
+	r.advance(1, time.Second)
+	require.Equal(t, []int{1}, r.receive())
+
+	r.send(2)
+	r.advance(1, time.Second)
+	require.Equal(t, []int{1, 2}, r.receive())
+
+	r.send(3)
+	r.advance(1, time.Second)
+	require.Equal(t, []int{1, 2, 3}, r.receive())
+
+	r.send(4)
+	r.advance(1, time.Second)
+	require.Equal(t, []int{2, 3, 4}, r.receive())
+
+	// Windows without new values are skipped even if they are not empty.
+	r.advance(1, time.Second)
+	r.requireNothing(1)
+
+	r.advance(1, 500*time.Millisecond)
+	r.send(5)
+	require.Equal(t, [][]int{{4, 5}}, r.close())
+}
+
+func TestDebounce(t *testing.T) {
+	r := startStage(t, func(opts ...StageOption) TypedStage[string, string] {
+		return Debounce[string](time.Second, opts...)
+	})
+
+	r.send("a")
+	r.advance(1, 500*time.Millisecond)
+	r.send("ab")
+	r.advance(1, 500*time.Millisecond)
+	r.send("abc")
+	r.advance(1, 999*time.Millisecond)
+	r.requireNothing(1)
+	r.advance(1, time.Millisecond)
+	require.Equal(t, "abc", r.receive())
+
+	r.send("x")
+	r.requireNothing(1)
+	require.Equal(t, []string{"x"}, r.close())
+}
+
+func TestThrottle(t *testing.T) {
+	r := startStage(t, func(opts ...StageOption) TypedStage[int, int] {
+		return Throttle[int](time.Second, opts...)
+	})
+
+	r.send(1, 2, 3)
+	require.Equal(t, 1, r.receive())
+	r.advance(1, 999*time.Millisecond)
+	r.send(4)
+	r.advance(1, time.Millisecond)
+	r.send(5, 6)
+	require.Equal(t, 5, r.receive())
+	r.advance(1, 5*time.Second)
+	r.send(7)
+	require.Equal(t, 7, r.receive())
+	r.requireNothing(1)
+	require.Empty(t, r.close())
+}
+
+func TestWindowStagesStopOnCancel(t *testing.T) {
+	wrap := Map(func(_ context.Context, v int) ([]int, error) { return []int{v}, nil })
+	stages := map[string]func(opts ...StageOption) TypedStage[int, []int]{
+		"batch": func(opts ...StageOption) TypedStage[int, []int] { return Batch[int](10, time.Second, opts...) },
+		"tumbling": func(opts ...StageOption) TypedStage[int, []int] {
+			return TumblingWindow[int](time.Second, opts...)
+		},
+		"sliding": func(opts ...StageOption) TypedStage[int, []int] {
+			return SlidingWindow[int](time.Second, time.Second, opts...)
+		},
+		"debounce": func(opts ...StageOption) TypedStage[int, []int] {
+			return Chain(Debounce[int](time.Second, opts...), wrap)
+		},
+		"throttle": func(opts ...StageOption) TypedStage[int, []int] {
+			return Chain(Throttle[int](time.Second, opts...), wrap)
+		},
+	}
+	for name, newStage := range stages {
+		t.Run(name, func(t *testing.T) {
+			r := startStage(t, newStage)
+			r.send(1)
+			r.cancel()
+
+			select {
+			case err := <-r.done:
+				require.NoError(t, err)
+			case <-time.After(time.Second):
+				require.FailNow(t, "stage did not stop")
+			}
+			require.Zero(t, r.clock.Timers(), "timers are left")
+		})
+	}
+}
+
+func TestWindowStagesInExecutePipeline(t *testing.T) {
+	feed := func(values ...interface{}) In {
+		in := make(Bi)
+		go func() {
+			defer close(in)
+			for _, v := range values {
+				in <- v
+			}
+		}()
+		return in
+	}
+	collect := func(out Out) []interface{} {
+		var result []interface{}
+		for v := range out {
+			result = append(result, v)
+		}
+		return result
+	}
+
+	t.Run("batches", func(t *testing.T) {
+		checkLeaks(t)
+		done := make(Bi)
+		defer close(done)
+
+		out := ExecutePipeline(feed(0, 1, 2, 3, 4, 5, 6), done, AsStage(Batch[int](3, 0), done))
+		require.Equal(t, []interface{}{[]int{0, 1, 2}, []int{3, 4, 5}, []int{6}}, collect(out))
+	})
+
+	t.Run("value of other type stops stage", func(t *testing.T) {
+		checkLeaks(t)
+		done := make(Bi)
+		defer close(done)
+
+		out := ExecutePipeline(feed(0, "one", 2), done, AsStage(Batch[int](3, 0), done))
+		require.Empty(t, collect(out))
+	})
+
+	t.Run("done stops stage", func(t *testing.T) {
+		checkLeaks(t)
+		release := make(chan struct{})
+		t.Cleanup(func() { close(release) })
+		in := make(Bi)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case in <- i:
+				case <-release:
+					return
+				}
+			}
+		}()
+		done := make(Bi)
+		time.AfterFunc(10*time.Millisecond, func() { close(done) })
+
+		stage := AsStage(Debounce[int](time.Hour), done)
+		require.Empty(t, collect(ExecutePipeline(in, done, stage)))
+	})
+}